@@ -0,0 +1,295 @@
+package touchsimulation
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// EventSource Supplies raw input events of a touch device
+type EventSource interface {
+	// ReadEvent Read next input event
+	ReadEvent() (InputEvent, error)
+	// Close Stop reading and give the device back
+	Close() error
+}
+
+// EventSink Accepts input events for a virtual touch device
+type EventSink interface {
+	// WriteEvent Emit input event to the device
+	WriteEvent(event InputEvent) error
+	// Close Destroy the device
+	Close() error
+}
+
+// DeviceError Failure of a setup or recovery step on a device. It matches its Kind, like
+// ErrGrabFailed, as well as the underlying error with errors.Is
+type DeviceError struct {
+	Kind error
+	Path string
+	Err  error
+}
+
+func (e *DeviceError) Error() string {
+	return e.Kind.Error() + ": " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *DeviceError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DeviceError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// DeviceSink EventSink backed by a real virtual device, reports where it showed up
+type DeviceSink interface {
+	EventSink
+	// Device Fetch the created device, Path and SysPath are empty when the kernel can't tell
+	Device() *InputDevice
+}
+
+// DeviceFactory Discovers touch devices and creates their sources and virtual clones
+type DeviceFactory interface {
+	// InputDevices Fetch available touch devices
+	InputDevices() ([]*InputDevice, error)
+	// OpenSource Take exclusive ownership of device's events
+	OpenSource(dev *InputDevice) (EventSource, error)
+	// CreateSink Create virtual clone of device for given mode
+	CreateSink(mode TypeMode, dev *InputDevice) (EventSink, error)
+}
+
+///----------Evdev & UInput-----------///
+
+// EvdevFactory DeviceFactory backed by /dev/input event devices and /dev/uinput
+type EvdevFactory struct{}
+
+// InputDevices Fetch touch devices from /dev/input
+func (EvdevFactory) InputDevices() ([]*InputDevice, error) {
+	return getInputDevices()
+}
+
+// OpenSource Grab event device exclusively and read from it
+func (EvdevFactory) OpenSource(dev *InputDevice) (EventSource, error) {
+	err := dev.Grab()
+	if err != nil {
+		return nil, &DeviceError{Kind: ErrGrabFailed, Path: dev.Path, Err: err}
+	}
+
+	src, err := newEvdevSource(dev)
+	if err != nil {
+		_ = dev.Release()
+		return nil, fmt.Errorf("open %s: %w", dev.Path, err)
+	}
+	return src, nil
+}
+
+// CreateSink Create UInput clone of event device
+func (EvdevFactory) CreateSink(mode TypeMode, dev *InputDevice) (EventSink, error) {
+	var uDev *InputDevice
+	var err error
+
+	switch mode {
+	case TYPEA:
+		uDev, err = newTypeADevSame(dev)
+	case TYPEARND:
+		uDev, err = newTypeADevRandom(dev)
+	default:
+		uDev, err = newTypeBDevSame(dev)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return &uinputSink{dev: uDev}, nil
+}
+
+// Events fetched by a single read syscall
+const evdevBatchSize = 64
+
+// evdevSource Reads a grabbed event device, sleeping in epoll while it has nothing to report
+type evdevSource struct {
+	dev      *InputDevice
+	fd       int
+	epfd     int
+	wake     [2]int
+	buffer   []byte
+	pending  []InputEvent
+	mu       sync.Mutex
+	closed   bool
+	wakeOnce sync.Once
+}
+
+func newEvdevSource(dev *InputDevice) (*evdevSource, error) {
+	src := &evdevSource{
+		dev:    dev,
+		fd:     int(dev.File.Fd()),
+		buffer: make([]byte, evdevBatchSize*inputEventSize),
+	}
+
+	// Fd() switched file to blocking mode, readiness is handled by epoll below
+	err := syscall.SetNonblock(src.fd, true)
+	if err != nil {
+		return nil, err
+	}
+
+	src.epfd, err = syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pipe used by Close to wake up a blocked reader
+	err = syscall.Pipe2(src.wake[:], syscall.O_NONBLOCK|syscall.O_CLOEXEC)
+	if err != nil {
+		_ = syscall.Close(src.epfd)
+		return nil, err
+	}
+
+	for _, fd := range []int{src.fd, src.wake[0]} {
+		err = syscall.EpollCtl(src.epfd, syscall.EPOLL_CTL_ADD, fd, &syscall.EpollEvent{
+			Events: syscall.EPOLLIN,
+			Fd:     int32(fd),
+		})
+		if err != nil {
+			src.closeFds()
+			return nil, err
+		}
+	}
+
+	return src, nil
+}
+
+// Block until device has events or source got closed
+func (src *evdevSource) waitReadable() error {
+	events := make([]syscall.EpollEvent, 2)
+
+	for {
+		n, err := syscall.EpollWait(src.epfd, events, -1)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < n; i++ {
+			if int(events[i].Fd) == src.wake[0] {
+				return os.ErrClosed
+			}
+		}
+		return nil
+	}
+}
+
+func (src *evdevSource) ReadEvent() (InputEvent, error) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	for len(src.pending) == 0 {
+		if src.closed {
+			return InputEvent{}, os.ErrClosed
+		}
+
+		n, err := syscall.Read(src.fd, src.buffer)
+		if err == syscall.EAGAIN || err == syscall.EINTR {
+			err = src.waitReadable()
+			if err != nil {
+				return InputEvent{}, err
+			}
+			continue
+		}
+		if err != nil {
+			return InputEvent{}, err
+		}
+		if n == 0 {
+			// Device vanished without reporting ENODEV
+			return InputEvent{}, syscall.ENODEV
+		}
+
+		src.pending, err = decodeInputEvents(src.buffer[:n])
+		if err != nil {
+			return InputEvent{}, err
+		}
+	}
+
+	event := src.pending[0]
+	src.pending = src.pending[1:]
+	return event, nil
+}
+
+func (src *evdevSource) Close() error {
+	// Kick reader out of epoll before taking its lock, only once since
+	// the wake fd number may belong to another file after closeFds
+	src.wakeOnce.Do(func() {
+		_, _ = syscall.Write(src.wake[1], []byte{0})
+	})
+
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	if src.closed {
+		return nil
+	}
+	src.closed = true
+	src.closeFds()
+
+	return src.dev.Release()
+}
+
+func (src *evdevSource) SlotValues(code int, slots int32) ([]int32, error) {
+	// struct input_mt_request_layout { __u32 code; __s32 values[slots]; }
+	request := make([]int32, slots+1)
+	request[0] = int32(code)
+
+	err := ioctl(uintptr(src.fd), EVIOCGMTSLOTS(len(request)*4), uintptr(unsafe.Pointer(&request[0])))
+	if err != nil {
+		return nil, err
+	}
+	return request[1:], nil
+}
+
+func (src *evdevSource) AbsValue(code int) (int32, error) {
+	absInfo := AbsInfo{}
+
+	err := ioctl(uintptr(src.fd), EVIOCGABS(code), uintptr(unsafe.Pointer(&absInfo)))
+	if err != nil {
+		return 0, err
+	}
+	return absInfo.Value, nil
+}
+
+func (src *evdevSource) KeyState(code int) (bool, error) {
+	keys := new([keyCnt / 8]byte)
+
+	err := ioctl(uintptr(src.fd), EVIOCGKEY(len(keys)), uintptr(unsafe.Pointer(keys)))
+	if err != nil {
+		return false, err
+	}
+	return hasSpecificKey(keys, code), nil
+}
+
+func (src *evdevSource) closeFds() {
+	_ = syscall.Close(src.epfd)
+	_ = syscall.Close(src.wake[0])
+	_ = syscall.Close(src.wake[1])
+}
+
+type uinputSink struct {
+	dev *InputDevice
+}
+
+func (sink *uinputSink) WriteEvent(event InputEvent) error {
+	_, err := sink.dev.File.Write(inputEventToBytes(event))
+	return err
+}
+
+func (sink *uinputSink) Device() *InputDevice {
+	return sink.dev
+}
+
+func (sink *uinputSink) Close() error {
+	_ = releaseDevice(sink.dev.File)
+	return sink.dev.File.Close()
+}
@@ -0,0 +1,244 @@
+package touchsimulation
+
+import (
+	"os"
+	"sort"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+///----------Virtual Device Builder-----------///
+
+// DeviceSpec Declarative description of a UInput device, materialized by CreateDevice
+type DeviceSpec struct {
+	Name       string
+	ID         InputID
+	Types      []int
+	Keys       []int
+	AbsInfos   map[int]AbsInfo
+	Props      []int
+	Effects    []int
+	EffectsMax uint32
+}
+
+// NewDeviceSpec Start spec of device with given name and id
+func NewDeviceSpec(name string, id InputID) *DeviceSpec {
+	return &DeviceSpec{
+		Name:     name,
+		ID:       id,
+		AbsInfos: make(map[int]AbsInfo),
+	}
+}
+
+// WithTypes Enable event types, EV_SYN is always enabled by the kernel
+func (spec *DeviceSpec) WithTypes(types ...int) *DeviceSpec {
+	for _, evType := range types {
+		spec.Types = addCode(spec.Types, evType)
+	}
+	return spec
+}
+
+// WithKeys Enable EV_KEY with given keys and buttons
+func (spec *DeviceSpec) WithKeys(keys ...int) *DeviceSpec {
+	spec.WithTypes(evKey)
+	for _, key := range keys {
+		spec.Keys = addCode(spec.Keys, key)
+	}
+	return spec
+}
+
+// WithAbs Enable EV_ABS with given axis and its range
+func (spec *DeviceSpec) WithAbs(code int, absInfo AbsInfo) *DeviceSpec {
+	spec.WithTypes(evAbs)
+	spec.AbsInfos[code] = absInfo
+	return spec
+}
+
+// WithProps Set input properties, like INPUT_PROP_DIRECT
+func (spec *DeviceSpec) WithProps(props ...int) *DeviceSpec {
+	for _, prop := range props {
+		spec.Props = addCode(spec.Props, prop)
+	}
+	return spec
+}
+
+// WithEffects Enable EV_FF with given effects, effectsMax effects can be uploaded at once
+func (spec *DeviceSpec) WithEffects(effectsMax uint32, effects ...int) *DeviceSpec {
+	spec.WithTypes(evFF)
+	for _, effect := range effects {
+		spec.Effects = addCode(spec.Effects, effect)
+	}
+	spec.EffectsMax = effectsMax
+	return spec
+}
+
+// Insert code into sorted code list, keeping it free of duplicates
+func addCode(codes []int, code int) []int {
+	idx := sort.SearchInts(codes, code)
+	if idx < len(codes) && codes[idx] == code {
+		return codes
+	}
+
+	codes = append(codes, 0)
+	copy(codes[idx+1:], codes[idx:])
+	codes[idx] = code
+	return codes
+}
+
+// CreateDevice Create UInput device described by spec, failing to open /dev/uinput matches ErrUinputUnavailable
+func CreateDevice(spec *DeviceSpec) (*InputDevice, error) {
+	//Open UInput
+	deviceFile, err := os.OpenFile("/dev/uinput", syscall.O_WRONLY|syscall.O_NONBLOCK, 0660)
+	if err != nil {
+		return nil, &DeviceError{Kind: ErrUinputUnavailable, Path: "/dev/uinput", Err: err}
+	}
+
+	err = spec.apply(deviceFile)
+	if err != nil {
+		_ = releaseDevice(deviceFile)
+		_ = deviceFile.Close()
+		return nil, err
+	}
+
+	//Declare Input Device
+	err = createDevice(deviceFile)
+	if err != nil {
+		_ = releaseDevice(deviceFile)
+		_ = deviceFile.Close()
+		return nil, err
+	}
+
+	time.Sleep(time.Millisecond * 200)
+
+	uDev := &InputDevice{
+		File: deviceFile,
+		Name: spec.Name,
+	}
+
+	//Find Event Node, kernels before 3.15 can't tell
+	_ = uDev.resolveNode()
+
+	return uDev, nil
+}
+
+// Set capability bits and describe device on opened UInput file
+func (spec *DeviceSpec) apply(f *os.File) error {
+	absCodes := make([]int, 0, len(spec.AbsInfos))
+	for code := range spec.AbsInfos {
+		absCodes = append(absCodes, code)
+	}
+	sort.Ints(absCodes)
+
+	bits := []struct {
+		request int
+		codes   []int
+	}{
+		{UISETEVBIT(), spec.Types},
+		{UISETKEYBIT(), spec.Keys},
+		{UISETABSBIT(), absCodes},
+		{UISETPROPBIT(), spec.Props},
+		{UISETFFBIT(), spec.Effects},
+	}
+
+	for _, bit := range bits {
+		for _, code := range bit.codes {
+			err := ioctl(f.Fd(), bit.request, uintptr(code))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	//Write to Input Sub-System
+	return setupDevice(f, spec.Name, spec.ID, spec.EffectsMax, spec.AbsInfos)
+}
+
+// Version of uinput interface, zero when it can't tell
+func uinputVersion(f *os.File) uint32 {
+	var version uint32
+
+	err := ioctl(f.Fd(), UIGETVERSION(), uintptr(unsafe.Pointer(&version)))
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+///----------Clone Profiles-----------///
+
+// Spec of Type-B device cloning every capability of Event device
+func typeBSpecSame(inputDev *InputDevice) *DeviceSpec {
+	spec := NewDeviceSpec(inputDev.Name+"2", inputDev.IID)
+
+	spec.WithKeys()
+	for i := 0; i <= keyMax; i++ {
+		if hasSpecificKey(inputDev.KeyBits, i) {
+			spec.WithKeys(i)
+		}
+	}
+
+	spec.WithTypes(evAbs)
+	for i := 0; i <= absMax; i++ {
+		if hasSpecificAbs(inputDev.AbsBits, i) {
+			spec.WithAbs(i, inputDev.AbsInfos[i])
+		}
+	}
+
+	for i := 0; i <= inputPropMax; i++ {
+		if hasSpecificProp(inputDev.PropBits, i) {
+			spec.WithProps(i)
+		}
+	}
+
+	if hasSpecificType(inputDev.Dbits, evFF) {
+		spec.EffectsMax = 10
+	}
+
+	return spec
+}
+
+// Spec of Type-A device with Event device's keys, positions and tracking ids
+func typeASpecSame(inputDev *InputDevice) *DeviceSpec {
+	spec := typeBSpecSame(inputDev)
+
+	// Make Sure only TypeA enabled ABS got set
+	for code := range spec.AbsInfos {
+		if code != absMtPositionX && code != absMtPositionY && code != absMtTrackingId {
+			delete(spec.AbsInfos, code)
+		}
+	}
+
+	return spec
+}
+
+// Spec of Type-A device with random name and id, covering Event device's area
+func typeASpecRandom(inputDev *InputDevice) *DeviceSpec {
+	spec := NewDeviceSpec(randStringBytes(7), InputID{
+		BusType: 0,
+		Vendor:  randUInt16Num(0x2000),
+		Product: randUInt16Num(0x2000),
+		Version: randUInt16Num(0x20),
+	})
+
+	spec.WithKeys(btnTouch)
+	spec.WithAbs(absMtPositionX, AbsInfo{
+		Minimum: inputDev.AbsInfos[absMtPositionX].Minimum,
+		Maximum: inputDev.AbsInfos[absMtPositionX].Maximum,
+	})
+	spec.WithAbs(absMtPositionY, AbsInfo{
+		Minimum: inputDev.AbsInfos[absMtPositionY].Minimum,
+		Maximum: inputDev.AbsInfos[absMtPositionY].Maximum,
+	})
+	spec.WithAbs(absMtTrackingId, AbsInfo{
+		Minimum: 0,
+		Maximum: inputDev.Slots - 1,
+	})
+	spec.WithProps(inputPropDirect)
+
+	if hasSpecificType(inputDev.Dbits, evFF) {
+		spec.EffectsMax = 10
+	}
+
+	return spec
+}
@@ -0,0 +1,146 @@
+package touchsimulation
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"syscall"
+	"testing"
+)
+
+func TestEvdevSourceCloseTwice(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	defer r.Close()
+
+	src, err := newEvdevSource(&InputDevice{File: r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wakeFd := src.wake[1]
+
+	// Pipe isn't an event device, releasing its grab fails
+	_ = src.Close()
+
+	//Put another pipe at the closed wake fd number
+	fds := make([]int, 2)
+	if err := syscall.Pipe2(fds, syscall.O_NONBLOCK|syscall.O_CLOEXEC); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(fds[0])
+	defer syscall.Close(fds[1])
+	if err := syscall.Dup3(fds[1], wakeFd, syscall.O_CLOEXEC); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(wakeFd)
+
+	if err := src.Close(); err != nil {
+		t.Errorf("second Close = %v, want nil", err)
+	}
+
+	if n, _ := syscall.Read(fds[0], make([]byte, 1)); n > 0 {
+		t.Error("second Close wrote to a reused fd")
+	}
+}
+
+func TestDeviceError(t *testing.T) {
+	err := error(&DeviceError{Kind: ErrGrabFailed, Path: "/dev/input/event3", Err: syscall.EBUSY})
+
+	if !errors.Is(err, ErrGrabFailed) || !errors.Is(err, syscall.EBUSY) {
+		t.Errorf("%v doesn't match its kind and cause", err)
+	}
+	if errors.Is(err, ErrUinputUnavailable) {
+		t.Errorf("%v matches other kind", err)
+	}
+	if want := "touch device grab failed: /dev/input/event3: device or resource busy"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestSetupErrors(t *testing.T) {
+	dev := newTestDevice()
+
+	tests := []struct {
+		name     string
+		factory  *FakeFactory
+		filter   DeviceFilter
+		want     []error
+		wantKind error
+		wantPath string
+	}{
+		{
+			name:    "no devices",
+			factory: NewFakeFactory(),
+			want:    []error{ErrNoDevice},
+		},
+		{
+			name:    "no device matches",
+			factory: NewFakeFactory(dev),
+			filter:  DeviceFilter{Name: regexp.MustCompile("^other$")},
+			want:    []error{ErrNoDevice},
+		},
+		{
+			name: "devices not permitted",
+			factory: &FakeFactory{
+				DevicesErr: &DeviceError{Kind: ErrNoDevice, Path: "/dev/input", Err: os.ErrPermission},
+			},
+			want:     []error{ErrNoDevice, os.ErrPermission},
+			wantKind: ErrNoDevice,
+			wantPath: "/dev/input",
+		},
+		{
+			name: "grab fails",
+			factory: &FakeFactory{
+				Devices:   []*InputDevice{dev},
+				Sink:      NewFakeSink(),
+				SourceErr: &DeviceError{Kind: ErrGrabFailed, Path: dev.Path, Err: syscall.EBUSY},
+			},
+			want:     []error{ErrGrabFailed, syscall.EBUSY},
+			wantKind: ErrGrabFailed,
+			wantPath: dev.Path,
+		},
+		{
+			name: "uinput missing",
+			factory: &FakeFactory{
+				Devices: []*InputDevice{dev},
+				SinkErr: &DeviceError{Kind: ErrUinputUnavailable, Path: "/dev/uinput", Err: os.ErrNotExist},
+			},
+			want:     []error{ErrUinputUnavailable, os.ErrNotExist},
+			wantKind: ErrUinputUnavailable,
+			wantPath: "/dev/uinput",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewSimulatorWithFactory(test.factory)
+
+			err := s.SetupFilter(TYPEB, 1080, 2340, test.filter)
+			for _, want := range test.want {
+				if !errors.Is(err, want) {
+					t.Errorf("SetupFilter = %v, want it to match %v", err, want)
+				}
+			}
+
+			var devErr *DeviceError
+			if test.wantKind != nil {
+				if !errors.As(err, &devErr) {
+					t.Fatalf("SetupFilter = %v, want DeviceError", err)
+				}
+				if devErr.Kind != test.wantKind || devErr.Path != test.wantPath {
+					t.Errorf("DeviceError kind %v path %s, want %v %s", devErr.Kind, devErr.Path, test.wantKind, test.wantPath)
+				}
+			}
+
+			if err := s.Down(0, 10, 10); !errors.Is(err, ErrNotStarted) {
+				t.Errorf("Down after failed setup = %v, want ErrNotStarted", err)
+			}
+			if test.factory.SourceErr != nil && !test.factory.Sink.isClosed() {
+				t.Error("clone got left behind after failed grab")
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package touchsimulation
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+///----------In-Memory Devices-----------///
+
+// FakeFactory DeviceFactory serving in-memory devices, for running the pipeline without /dev/input.
+// Source and Sink get handed out until closed, then fresh ones replace them like for a new device.
+type FakeFactory struct {
+	Devices []*InputDevice
+	Source  *FakeSource
+	Sink    *FakeSink
+
+	// DevicesErr, SourceErr and SinkErr Failures returned instead of devices, source and sink when set
+	DevicesErr error
+	SourceErr  error
+	SinkErr    error
+
+	mu      sync.Mutex
+	changed chan struct{}
+}
+
+// NewFakeFactory Create FakeFactory offering given devices
+func NewFakeFactory(devs ...*InputDevice) *FakeFactory {
+	return &FakeFactory{
+		Devices: devs,
+		Source:  NewFakeSource(),
+		Sink:    NewFakeSink(),
+		changed: make(chan struct{}),
+	}
+}
+
+// InputDevices Fetch fake touch devices
+func (f *FakeFactory) InputDevices() ([]*InputDevice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.DevicesErr != nil {
+		return nil, f.DevicesErr
+	}
+	if len(f.Devices) < 1 {
+		return nil, ErrNoDevice
+	}
+	return f.Devices, nil
+}
+
+// SetDevices Replace fake touch devices, like when they got unplugged or came back
+func (f *FakeFactory) SetDevices(devs ...*InputDevice) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Devices = devs
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// WaitDevices Block until SetDevices got called, or stop got closed returning ErrNotStarted
+func (f *FakeFactory) WaitDevices(stop <-chan bool) error {
+	f.mu.Lock()
+	changed := f.changed
+	f.mu.Unlock()
+
+	select {
+	case <-changed:
+		return nil
+	case <-stop:
+		return ErrNotStarted
+	}
+}
+
+// OpenSource Hand out the scripted source, a fresh one once it got closed
+func (f *FakeFactory) OpenSource(dev *InputDevice) (EventSource, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.SourceErr != nil {
+		return nil, f.SourceErr
+	}
+	if f.Source.isClosed() {
+		f.Source = NewFakeSource()
+	}
+	return f.Source, nil
+}
+
+// CreateSink Hand out the recording sink, a fresh one once it got closed
+func (f *FakeFactory) CreateSink(mode TypeMode, dev *InputDevice) (EventSink, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.SinkErr != nil {
+		return nil, f.SinkErr
+	}
+	if f.Sink.isClosed() {
+		f.Sink = NewFakeSink()
+	}
+	return f.Sink, nil
+}
+
+// CurrentSource Fetch source handed out last
+func (f *FakeFactory) CurrentSource() *FakeSource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.Source
+}
+
+// CurrentSink Fetch sink handed out last
+func (f *FakeFactory) CurrentSink() *FakeSink {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.Sink
+}
+
+// NewFakeTouchDevice Create touch device description with given Abs axes, its protocol follows from them
+func NewFakeTouchDevice(name string, absInfos map[int]AbsInfo) *InputDevice {
+	dev := &InputDevice{
+		Name:     name,
+		Path:     "/dev/input/fake",
+		Dbits:    new([evCnt / 8]byte),
+		AbsBits:  new([absCnt / 8]byte),
+		KeyBits:  new([keyCnt / 8]byte),
+		PropBits: new([inputPropCnt / 8]byte),
+	}
+
+	setBit(dev.Dbits[:], evSyn)
+	setBit(dev.Dbits[:], evKey)
+	setBit(dev.Dbits[:], evAbs)
+	setBit(dev.KeyBits[:], btnTouch)
+	setBit(dev.PropBits[:], inputPropDirect)
+
+	for key, absInfo := range absInfos {
+		setBit(dev.AbsBits[:], key)
+		dev.setAbsInfo(key, absInfo)
+	}
+	dev.classify()
+
+	return dev
+}
+
+// Set key in bitset
+func setBit(bits []byte, key int) {
+	bits[key/8] |= 1 << uint(key%8)
+}
+
+// FakeSource EventSource fed with scripted events, its device state is used for SYN_DROPPED resync
+type FakeSource struct {
+	events chan InputEvent
+	failed chan error
+	done   chan struct{}
+	once   sync.Once
+
+	mu    sync.Mutex
+	slots map[int]map[int32]int32
+	abs   map[int]int32
+	keys  map[int]bool
+}
+
+// NewFakeSource Create empty FakeSource
+func NewFakeSource() *FakeSource {
+	return &FakeSource{
+		events: make(chan InputEvent, 1024),
+		failed: make(chan error, 1),
+		done:   make(chan struct{}),
+		slots:  make(map[int]map[int32]int32),
+		abs:    make(map[int]int32),
+		keys:   make(map[int]bool),
+	}
+}
+
+// SetSlotValue Set current value of MT axis in slot, tracking ids default to -1 and other axes to 0
+func (src *FakeSource) SetSlotValue(code int, slot, value int32) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	if src.slots[code] == nil {
+		src.slots[code] = make(map[int32]int32)
+	}
+	src.slots[code][slot] = value
+}
+
+// SetAbsValue Set current value of Abs axis
+func (src *FakeSource) SetAbsValue(code int, value int32) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	src.abs[code] = value
+}
+
+// SetKeyState Set whether key is pressed
+func (src *FakeSource) SetKeyState(code int, down bool) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	src.keys[code] = down
+}
+
+// SlotValues Fetch current value of MT axis for each slot
+func (src *FakeSource) SlotValues(code int, slots int32) ([]int32, error) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	values := make([]int32, slots)
+	for slot := range values {
+		value, ok := src.slots[code][int32(slot)]
+		if !ok && code == absMtTrackingId {
+			value = -1
+		}
+		values[slot] = value
+	}
+	return values, nil
+}
+
+// AbsValue Fetch current value of Abs axis
+func (src *FakeSource) AbsValue(code int) (int32, error) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	return src.abs[code], nil
+}
+
+// KeyState Determine if key is pressed
+func (src *FakeSource) KeyState(code int) (bool, error) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	return src.keys[code], nil
+}
+
+// Feed Queue events to be read in order
+func (src *FakeSource) Feed(events ...InputEvent) {
+	for _, event := range events {
+		src.events <- event
+	}
+}
+
+// Fail Make next read fail with err after queued events, like ENODEV of an unplugged device
+func (src *FakeSource) Fail(err error) {
+	src.failed <- err
+}
+
+// ReadEvent Block until next queued event, fails with io.EOF once closed
+func (src *FakeSource) ReadEvent() (InputEvent, error) {
+	select {
+	case event := <-src.events:
+		return event, nil
+	default:
+	}
+
+	select {
+	case event := <-src.events:
+		return event, nil
+	case err := <-src.failed:
+		return InputEvent{}, err
+	case <-src.done:
+		return InputEvent{}, io.EOF
+	}
+}
+
+// Close Wake up blocked readers
+func (src *FakeSource) Close() error {
+	src.once.Do(func() {
+		close(src.done)
+	})
+	return nil
+}
+
+// Determine if source got closed
+func (src *FakeSource) isClosed() bool {
+	select {
+	case <-src.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// FakeSink EventSink recording every written event
+type FakeSink struct {
+	mu      sync.Mutex
+	events  []InputEvent
+	reports int
+	closed  bool
+}
+
+// NewFakeSink Create empty FakeSink
+func NewFakeSink() *FakeSink {
+	return &FakeSink{}
+}
+
+// WriteEvent Record event
+func (sink *FakeSink) WriteEvent(event InputEvent) error {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	if sink.closed {
+		return io.ErrClosedPipe
+	}
+
+	sink.events = append(sink.events, event)
+	if event.Type == evSyn && event.Code == synReport {
+		sink.reports++
+	}
+	return nil
+}
+
+// Close Mark sink as destroyed
+func (sink *FakeSink) Close() error {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	sink.closed = true
+	return nil
+}
+
+// Determine if sink got closed
+func (sink *FakeSink) isClosed() bool {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	return sink.closed
+}
+
+// Events Fetch copy of recorded events
+func (sink *FakeSink) Events() []InputEvent {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	events := make([]InputEvent, len(sink.events))
+	copy(events, sink.events)
+	return events
+}
+
+// WaitReports Wait until at least n SYN_REPORT frames got recorded
+func (sink *FakeSink) WaitReports(n int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		sink.mu.Lock()
+		reports := sink.reports
+		sink.mu.Unlock()
+
+		if reports >= n {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,135 @@
+package touchsimulation
+
+import (
+	"errors"
+)
+
+///----------Fake Touch Frames-----------///
+
+// PointerAction Change a PointerOp makes to its fake pointer
+type PointerAction int
+
+const (
+	POINTERDOWN PointerAction = iota
+	POINTERMOVE
+	POINTERUP
+)
+
+var ErrBadPointerOp = errors.New("unknown pointer action")
+
+// PointerOp One fake pointer change within a frame, coordinates are display ones
+type PointerOp struct {
+	Action PointerAction
+	Id     int
+	X      int32
+	Y      int32
+	// Shape New contact shape of pointer, kept as is when nil
+	Shape *ContactShape
+}
+
+// DownOp Touch down fake pointer id at display coordinates
+func DownOp(id int, x, y int32) PointerOp {
+	return PointerOp{Action: POINTERDOWN, Id: id, X: x, Y: y}
+}
+
+// MoveOp Move fake pointer id to display coordinates
+func MoveOp(id int, x, y int32) PointerOp {
+	return PointerOp{Action: POINTERMOVE, Id: id, X: x, Y: y}
+}
+
+// UpOp Lift fake pointer id
+func UpOp(id int) PointerOp {
+	return PointerOp{Action: POINTERUP, Id: id}
+}
+
+// Frame Apply pointer changes together, the dispatcher reports all of them in the same
+// SYN_REPORT frame, so multi-finger gestures move their fingers at once.
+// Ops are checked up front, either every one of them gets applied or none.
+func (s *Simulator) Frame(ops ...PointerOp) error {
+	s.mu.Lock()
+
+	if err := s.checkRunning(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	if err := s.checkFrame(ops); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	for _, op := range ops {
+		switch op.Action {
+		case POINTERDOWN:
+			x, y := s.toTouchCoords(op.X, op.Y)
+			_ = s.downPointer(op.Id, x, y)
+			if op.Shape != nil {
+				_ = s.shapePointer(op.Id, *op.Shape)
+			}
+			break
+		case POINTERMOVE:
+			x, y := s.toTouchCoords(op.X, op.Y)
+			_ = s.movePointer(op.Id, x, y)
+			if op.Shape != nil {
+				_ = s.shapePointer(op.Id, *op.Shape)
+			}
+			break
+		case POINTERUP:
+			_ = s.upPointer(op.Id)
+			break
+		}
+	}
+
+	s.mu.Unlock()
+
+	if len(ops) > 0 {
+		s.notify()
+	}
+	return nil
+}
+
+// Check ops of frame against fake pointer states and free slots, lock must be held
+func (s *Simulator) checkFrame(ops []PointerOp) error {
+	down := make(map[int]bool)
+	for id := range s.fakePointers {
+		down[id] = true
+	}
+
+	downs := 0
+	for _, op := range ops {
+		switch op.Action {
+		case POINTERDOWN:
+			if down[op.Id] {
+				return ErrPointerDown
+			}
+			down[op.Id] = true
+			downs++
+			break
+		case POINTERMOVE:
+			if !down[op.Id] {
+				return ErrPointerUp
+			}
+			break
+		case POINTERUP:
+			if !down[op.Id] {
+				return ErrPointerUp
+			}
+			down[op.Id] = false
+			break
+		default:
+			return ErrBadPointerOp
+		}
+	}
+
+	// Slots lifted within the frame aren't counted, Type-B reuses them only after dispatch
+	free := 0
+	for slot := int32(0); slot < s.touchDevice.Slots; slot++ {
+		if s.isFreeSlot(slot) {
+			free++
+		}
+	}
+	if downs > free {
+		return ErrNoFreeSlot
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package touchsimulation
+
+import (
+	"testing"
+)
+
+func TestFrameTypeA(t *testing.T) {
+	s, sink := newSteppedSimulator(TYPEA, newTestDevice())
+
+	if err := s.Frame(DownOp(0, 400, 1170), DownOp(1, 680, 1170)); err != nil {
+		t.Fatal(err)
+	}
+	<-s.syncChannel
+	s.dispatchA()
+
+	reports, frames := 0, 0
+	for _, event := range sink.Events() {
+		if event.Type == evSyn && event.Code == synMtReport {
+			reports++
+		}
+		if event.Type == evSyn && event.Code == synReport {
+			frames++
+		}
+	}
+	if reports != 2 || frames != 1 {
+		t.Errorf("got %d contacts in %d frames, want 2 contacts in 1 frame", reports, frames)
+	}
+}
+
+func TestFrameAllOrNothing(t *testing.T) {
+	s, _ := newSteppedSimulator(TYPEB, newTestDevice())
+
+	tests := []struct {
+		ops  []PointerOp
+		want error
+	}{
+		{[]PointerOp{DownOp(0, 10, 10), MoveOp(1, 20, 20)}, ErrPointerUp},
+		{[]PointerOp{DownOp(0, 10, 10), DownOp(0, 20, 20)}, ErrPointerDown},
+		{[]PointerOp{DownOp(0, 10, 10), UpOp(0), UpOp(0)}, ErrPointerUp},
+		{[]PointerOp{{Action: PointerAction(9)}}, ErrBadPointerOp},
+	}
+
+	for i, test := range tests {
+		if err := s.Frame(test.ops...); err != test.want {
+			t.Errorf("frame %d: got %v, want %v", i, err, test.want)
+		}
+		if len(s.fakePointers) != 0 {
+			t.Fatalf("frame %d: rejected frame left %d fake pointers", i, len(s.fakePointers))
+		}
+	}
+
+	var ops []PointerOp
+	for id := 0; id < 11; id++ {
+		ops = append(ops, DownOp(id, int32(10*id), 10))
+	}
+	if err := s.Frame(ops...); err != ErrNoFreeSlot {
+		t.Errorf("11 fingers on 10 slots: got %v, want ErrNoFreeSlot", err)
+	}
+}
@@ -0,0 +1,215 @@
+package touchsimulation
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"syscall"
+	"time"
+)
+
+///----------Device Loss & Hotplug-----------///
+
+// DeviceWatcher DeviceFactory able to tell when input devices show up
+type DeviceWatcher interface {
+	// WaitDevices Block until devices may have got added, or stop got closed returning ErrNotStarted
+	WaitDevices(stop <-chan bool) error
+}
+
+// Longest wait for a device event before scanning again anyway
+const rescanInterval = 5 * time.Second
+
+// Determine if reading failed because the device went away, like on touch driver resets
+func isDeviceLost(err error) bool {
+	return errors.Is(err, syscall.ENODEV)
+}
+
+// SetAutoRebridge Choose whether a lost touch device gets waited for and bridged again, enabled by default
+func (s *Simulator) SetAutoRebridge(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.autoRebridge = enabled
+}
+
+// SetStatusHandler Receive messages about losing and bridging the touch device again, nil discards them
+func (s *Simulator) SetStatusHandler(handler func(message string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.statusHandler = handler
+}
+
+// Pass status message to the handler, lock must not be held
+func (s *Simulator) status(format string, args ...interface{}) {
+	s.mu.Lock()
+	handler := s.statusHandler
+	s.mu.Unlock()
+
+	if handler != nil {
+		handler(fmt.Sprintf(format, args...))
+	}
+}
+
+// Filter matching the same device after it came back, its event node may differ
+func sameDeviceFilter(dev *InputDevice) DeviceFilter {
+	return DeviceFilter{
+		Name:    regexp.MustCompile("^" + regexp.QuoteMeta(dev.Name) + "$"),
+		Vendor:  dev.IID.Vendor,
+		Product: dev.IID.Product,
+	}
+}
+
+// Tear down bridge of lost device, then wait for it to return and bridge it again.
+// Fake pointers die with the old clone; injecting fails with ErrDeviceLost meanwhile.
+func (s *Simulator) rebridge() {
+	defer s.wg.Done()
+
+	s.mu.Lock()
+	lostDev := s.touchDevice
+	source, sink := s.source, s.sink
+	s.source = nil
+	s.sink = nil
+	s.lost = true
+	s.mu.Unlock()
+
+	s.status("%s is gone, waiting for it to return", lostDev.Path)
+
+	_ = source.Close()
+	_ = sink.Close()
+	if lostDev.File != nil {
+		_ = lostDev.File.Close()
+	}
+
+	filter := sameDeviceFilter(lostDev)
+
+	for {
+		select {
+		case <-s.stopChannel:
+			return
+		default:
+		}
+
+		tDevs, err := s.factory.InputDevices()
+		if err == nil {
+			selected := SelectDevices(tDevs, filter)
+			if len(selected) > 0 {
+				CloseDevices(tDevs, selected[0])
+
+				err = s.resumeBridge(selected[0])
+				if err == nil {
+					s.status("%s is back at %s", lostDev.Name, selected[0].Path)
+					return
+				}
+				s.status("rebridge failed: %v", err)
+			} else {
+				CloseDevices(tDevs, nil)
+			}
+		}
+
+		if errors.Is(s.waitDevices(), ErrNotStarted) {
+			return
+		}
+	}
+}
+
+// Clone and grab returned device, then resume reading it
+func (s *Simulator) resumeBridge(inDev *InputDevice) error {
+	s.mu.Lock()
+	mode := s.currMode
+	s.mu.Unlock()
+
+	source, sink, err := s.openBridge(mode, inDev)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	//Stop came first, nobody would close these
+	select {
+	case <-s.stopChannel:
+		_ = source.Close()
+		_ = sink.Close()
+		return ErrNotStarted
+	default:
+	}
+
+	s.source = source
+	s.sink = sink
+	s.touchDevice = inDev
+	s.readErr = nil
+	s.lost = false
+
+	s.resetContacts()
+	s.startReader()
+
+	return nil
+}
+
+// Wait for devices to show up, fails with ErrNotStarted once Stop got called
+func (s *Simulator) waitDevices() error {
+	if watcher, ok := s.factory.(DeviceWatcher); ok {
+		return watcher.WaitDevices(s.stopChannel)
+	}
+
+	select {
+	case <-time.After(time.Second):
+		return nil
+	case <-s.stopChannel:
+		return ErrNotStarted
+	}
+}
+
+// WaitDevices Block until an event node got created or its permissions changed in /dev/input
+func (EvdevFactory) WaitDevices(stop <-chan bool) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_NONBLOCK | syscall.IN_CLOEXEC)
+	if err != nil {
+		return waitRescan(stop)
+	}
+
+	// Non-blocking fd gets serviced by the runtime poller, Close wakes up Read
+	inotify := os.NewFile(uintptr(fd), "inotify")
+	defer inotify.Close()
+
+	// ueventd changes permissions after creating the node, wait for that too
+	_, err = syscall.InotifyAddWatch(fd, "/dev/input", syscall.IN_CREATE|syscall.IN_ATTRIB)
+	if err != nil {
+		return waitRescan(stop)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		buffer := make([]byte, 4096)
+		_, err := inotify.Read(buffer)
+		done <- err
+	}()
+
+	select {
+	case err = <-done:
+		//Watch broke, fall back to scanning periodically
+		if err != nil {
+			return waitRescan(stop)
+		}
+		return nil
+	case <-time.After(rescanInterval):
+	case <-stop:
+		err = ErrNotStarted
+	}
+
+	_ = inotify.Close()
+	<-done
+	return err
+}
+
+// Sleep until next scan when device events can't be watched
+func waitRescan(stop <-chan bool) error {
+	select {
+	case <-time.After(rescanInterval):
+		return nil
+	case <-stop:
+		return ErrNotStarted
+	}
+}
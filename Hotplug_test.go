@@ -0,0 +1,102 @@
+package touchsimulation
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// Poll cond until it holds, failing the test after a second
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Start simulator on fake device, then unplug it. Status messages arrive on the returned channel.
+func startAndLose(t *testing.T) (*Simulator, *FakeFactory, *InputDevice, chan string) {
+	t.Helper()
+
+	dev := newTestDevice()
+	factory := NewFakeFactory(dev)
+	s := NewSimulatorWithFactory(factory)
+
+	messages := make(chan string, 16)
+	s.SetStatusHandler(func(message string) {
+		messages <- message
+	})
+	if err := s.Start(TYPEB, 1080, 2340, dev); err != nil {
+		t.Fatal(err)
+	}
+
+	factory.SetDevices()
+	factory.CurrentSource().Fail(syscall.ENODEV)
+
+	waitUntil(t, "device loss", func() bool {
+		return errors.Is(s.Down(0, 100, 100), ErrDeviceLost)
+	})
+	return s, factory, dev, messages
+}
+
+func TestRebridgeAfterDeviceReturns(t *testing.T) {
+	s, factory, dev, messages := startAndLose(t)
+	defer s.Stop()
+
+	lostSource, lostSink := factory.CurrentSource(), factory.CurrentSink()
+	if !lostSource.isClosed() || !lostSink.isClosed() {
+		t.Fatal("bridge of lost device didn't get torn down")
+	}
+	if !errors.Is(s.Err(), syscall.ENODEV) {
+		t.Errorf("Err() = %v, want ENODEV", s.Err())
+	}
+
+	factory.SetDevices(dev)
+
+	waitUntil(t, "rebridge", func() bool {
+		return s.Down(0, 100, 100) == nil
+	})
+
+	if factory.CurrentSource() == lostSource || factory.CurrentSink() == lostSink {
+		t.Fatal("rebridge reused closed source or sink")
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("Err() = %v after rebridge, want nil", err)
+	}
+	if !factory.CurrentSink().WaitReports(1, time.Second) {
+		t.Error("fake pointer didn't reach the new sink")
+	}
+
+	for _, want := range []string{"/dev/input/fake is gone", "is back at /dev/input/fake"} {
+		if message := <-messages; !strings.Contains(message, want) {
+			t.Errorf("status %q, want it to mention %q", message, want)
+		}
+	}
+}
+
+func TestStopWhileWaitingForDevice(t *testing.T) {
+	s, _, _, _ := startAndLose(t)
+
+	stopped := make(chan bool)
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop hangs while waiting for lost device")
+	}
+
+	if err := s.Down(0, 100, 100); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Down after Stop = %v, want ErrNotStarted", err)
+	}
+}
@@ -0,0 +1,173 @@
+package touchsimulation
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+///----------Device Profiles-----------///
+
+// Highest ABS_MT_SLOT maximum a profile may declare, far beyond what panels report
+const profileMaxSlot = 63
+
+// DeviceProfile Capability snapshot of an input device, stored as JSON so a
+// touchscreen can be cloned on hosts which don't have the physical panel
+type DeviceProfile struct {
+	Name     string          `json:"name"`
+	Version  int32           `json:"version"`
+	IID      InputID         `json:"id"`
+	Protocol TouchProtocol   `json:"protocol"`
+	Types    []int           `json:"types"`
+	Keys     []int           `json:"keys"`
+	Props    []int           `json:"props"`
+	AbsInfos map[int]AbsInfo `json:"abs"`
+}
+
+// NewDeviceProfile Take capability snapshot of device, leaving out axes emulated for Type-A and single-touch devices
+func NewDeviceProfile(dev *InputDevice) *DeviceProfile {
+	profile := &DeviceProfile{
+		Name:     dev.Name,
+		Version:  dev.Version,
+		IID:      dev.IID,
+		Protocol: dev.Protocol,
+		AbsInfos: make(map[int]AbsInfo),
+	}
+
+	for i := 0; i <= evMax; i++ {
+		if dev.Dbits != nil && hasSpecificType(dev.Dbits, i) {
+			profile.Types = append(profile.Types, i)
+		}
+	}
+	for i := 0; i <= keyMax; i++ {
+		if dev.KeyBits != nil && hasSpecificKey(dev.KeyBits, i) {
+			profile.Keys = append(profile.Keys, i)
+		}
+	}
+	for i := 0; i <= inputPropMax; i++ {
+		if dev.PropBits != nil && hasSpecificProp(dev.PropBits, i) {
+			profile.Props = append(profile.Props, i)
+		}
+	}
+	//Emulated axes get added again when the profile is turned into a device
+	for i := 0; i <= absMax; i++ {
+		if dev.AbsBits != nil && hasSpecificAbs(dev.AbsBits, i) && !dev.emulatedAbs[i] {
+			profile.AbsInfos[i] = dev.AbsInfos[i]
+		}
+	}
+
+	return profile
+}
+
+// Device Rebuild device description from profile, it has no File to read from
+func (profile *DeviceProfile) Device() *InputDevice {
+	dev := &InputDevice{
+		Name:     profile.Name,
+		Path:     "profile:" + profile.Name,
+		Version:  profile.Version,
+		IID:      profile.IID,
+		Dbits:    new([evCnt / 8]byte),
+		AbsBits:  new([absCnt / 8]byte),
+		KeyBits:  new([keyCnt / 8]byte),
+		PropBits: new([inputPropCnt / 8]byte),
+	}
+
+	for _, evType := range profile.Types {
+		setBit(dev.Dbits[:], evType)
+	}
+	for _, key := range profile.Keys {
+		setBit(dev.KeyBits[:], key)
+	}
+	for _, prop := range profile.Props {
+		setBit(dev.PropBits[:], prop)
+	}
+	for key, absInfo := range profile.AbsInfos {
+		setBit(dev.AbsBits[:], key)
+		dev.setAbsInfo(key, absInfo)
+	}
+
+	// Older snapshots carry emulated axes, which hide the protocol from classify
+	dev.classify()
+	dev.Protocol = profile.Protocol
+
+	return dev
+}
+
+// SaveProfile Write capability snapshot of device as JSON
+func SaveProfile(w io.Writer, dev *InputDevice) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewDeviceProfile(dev))
+}
+
+// LoadProfile Read profile written by SaveProfile
+func LoadProfile(r io.Reader) (*DeviceProfile, error) {
+	profile := &DeviceProfile{}
+
+	err := json.NewDecoder(r).Decode(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, absInfo := range profile.AbsInfos {
+		if key < 0 || key > absMax {
+			return nil, errors.New("profile has invalid abs axis")
+		}
+		if absInfo.Minimum > absInfo.Maximum {
+			return nil, errors.New("profile has abs axis with minimum above maximum")
+		}
+		if key == absMtSlot && (absInfo.Maximum < 0 || absInfo.Maximum > profileMaxSlot) {
+			return nil, errors.New("profile has invalid slot count")
+		}
+	}
+	for _, key := range profile.Keys {
+		if key < 0 || key > keyMax {
+			return nil, errors.New("profile has invalid key")
+		}
+	}
+	for _, evType := range profile.Types {
+		if evType < 0 || evType > evMax {
+			return nil, errors.New("profile has invalid event type")
+		}
+	}
+	for _, prop := range profile.Props {
+		if prop < 0 || prop > inputPropMax {
+			return nil, errors.New("profile has invalid property")
+		}
+	}
+
+	return profile, nil
+}
+
+// ProfileFactory DeviceFactory offering devices rebuilt from profiles. UInput
+// clones are created as with EvdevFactory, while sources stay idle since there
+// is no panel behind them, so the clone only carries injected touches.
+type ProfileFactory struct {
+	EvdevFactory
+	Profiles []*DeviceProfile
+}
+
+// NewProfileFactory Create ProfileFactory offering given profiles
+func NewProfileFactory(profiles ...*DeviceProfile) *ProfileFactory {
+	return &ProfileFactory{
+		Profiles: profiles,
+	}
+}
+
+// InputDevices Fetch devices described by profiles
+func (f *ProfileFactory) InputDevices() ([]*InputDevice, error) {
+	if len(f.Profiles) < 1 {
+		return nil, ErrNoDevice
+	}
+
+	devs := make([]*InputDevice, len(f.Profiles))
+	for i, profile := range f.Profiles {
+		devs[i] = profile.Device()
+	}
+	return devs, nil
+}
+
+// OpenSource Hand out source which never reports events
+func (f *ProfileFactory) OpenSource(dev *InputDevice) (EventSource, error) {
+	return NewFakeSource(), nil
+}
@@ -0,0 +1,70 @@
+package touchsimulation
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProfileLeavesOutEmulatedAxes(t *testing.T) {
+	tests := []struct {
+		name     string
+		dev      *InputDevice
+		emulated []int
+	}{
+		{"single-touch", newSingleTestDevice(), []int{absMtPositionX, absMtPositionY, absMtPressure, absMtSlot, absMtTrackingId}},
+		{"type-a", newTypeATestDevice(), []int{absMtSlot, absMtTrackingId}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			if err := SaveProfile(buffer, test.dev); err != nil {
+				t.Fatal(err)
+			}
+			profile, err := LoadProfile(buffer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, key := range test.emulated {
+				if _, ok := profile.AbsInfos[key]; ok {
+					t.Errorf("profile has emulated abs axis %#x", key)
+				}
+			}
+
+			dev := profile.Device()
+			if dev.Protocol != test.dev.Protocol || dev.Slots != test.dev.Slots {
+				t.Errorf("rebuilt protocol %d with %d slots, want %d with %d", dev.Protocol, dev.Slots, test.dev.Protocol, test.dev.Slots)
+			}
+			for _, key := range test.emulated {
+				if !dev.hasAbs(key) || dev.AbsInfos[key] != test.dev.AbsInfos[key] {
+					t.Errorf("rebuilt abs axis %#x = %+v, want emulated %+v", key, dev.AbsInfos[key], test.dev.AbsInfos[key])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadProfileAxisRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		abs     string
+		wantErr bool
+	}{
+		{"ten slots", `"47": {"minimum": 0, "maximum": 9}`, false},
+		{"negative slot maximum", `"47": {"minimum": 0, "maximum": -5}`, true},
+		{"too many slots", `"47": {"minimum": 0, "maximum": 100000}`, true},
+		{"minimum above maximum", `"53": {"minimum": 1079, "maximum": 0}`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := `{"name": "bad", "types": [0, 1, 3], "keys": [330], "abs": {` + test.abs + `}}`
+			_, err := LoadProfile(strings.NewReader(data))
+			if (err != nil) != test.wantErr {
+				t.Errorf("LoadProfile error = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
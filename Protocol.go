@@ -0,0 +1,363 @@
+package touchsimulation
+
+import (
+	"sort"
+)
+
+///----------Touch Protocols-----------///
+
+// TouchProtocol Way a touch device reports its contacts
+type TouchProtocol int
+
+const (
+	// PROTOCOLB Multi-touch contacts kept in slots
+	PROTOCOLB TouchProtocol = iota
+	// PROTOCOLA Multi-touch contacts separated by SYN_MT_REPORT, without slots
+	PROTOCOLA
+	// PROTOCOLSINGLE Single contact reported on ABS_X/ABS_Y
+	PROTOCOLSINGLE
+)
+
+// Contacts tracked for devices without slots
+const typeASlots = 10
+
+func (protocol TouchProtocol) String() string {
+	switch protocol {
+	case PROTOCOLB:
+		return "Type-B"
+	case PROTOCOLA:
+		return "Type-A"
+	case PROTOCOLSINGLE:
+		return "Single-Touch"
+	}
+	return "Unknown"
+}
+
+// Determine protocol of device from its capabilities, ok is false for non-touch devices
+func classifyProtocol(absBits *[absCnt / 8]byte, keyBits *[keyCnt / 8]byte) (protocol TouchProtocol, ok bool) {
+	// Devices with ABS_MT_SLOT - 1 aren't MT devices, libevdev:libevdev.c#L319
+	isMt := !hasSpecificAbs(absBits, absMtSlot-1) &&
+		hasSpecificAbs(absBits, absMtPositionX) &&
+		hasSpecificAbs(absBits, absMtPositionY)
+
+	if isMt && hasSpecificAbs(absBits, absMtSlot) && hasSpecificAbs(absBits, absMtTrackingId) {
+		return PROTOCOLB, true
+	}
+	if isMt {
+		return PROTOCOLA, true
+	}
+
+	// BTN_TOUCH tells touch panels apart from joysticks and sensors
+	if hasSpecificAbs(absBits, absX) && hasSpecificAbs(absBits, absY) && hasSpecificKey(keyBits, btnTouch) {
+		return PROTOCOLSINGLE, true
+	}
+	return PROTOCOLB, false
+}
+
+// Classify device, for Type-A and single-touch devices slot axes get emulated
+// so the rest of the simulation can treat every device as Type-B
+func (dev *InputDevice) classify() bool {
+	protocol, ok := classifyProtocol(dev.AbsBits, dev.KeyBits)
+	if !ok {
+		return false
+	}
+
+	dev.Protocol = protocol
+	dev.Direct = hasSpecificProp(dev.PropBits, inputPropDirect)
+
+	if protocol == PROTOCOLSINGLE {
+		dev.emulateAbs(absMtPositionX, dev.AbsInfos[absX])
+		dev.emulateAbs(absMtPositionY, dev.AbsInfos[absY])
+		if dev.hasAbs(absPressure) {
+			dev.emulateAbs(absMtPressure, dev.AbsInfos[absPressure])
+		}
+		dev.emulateAbs(absMtSlot, AbsInfo{Maximum: 0})
+	} else if protocol == PROTOCOLA {
+		dev.emulateAbs(absMtSlot, AbsInfo{Maximum: typeASlots - 1})
+	}
+
+	if !dev.hasAbs(absMtTrackingId) {
+		dev.emulateAbs(absMtTrackingId, AbsInfo{Maximum: 0xFFFF})
+	}
+
+	dev.updateCaps()
+	return true
+}
+
+// Add axis the device doesn't report itself, remembering it isn't real
+func (dev *InputDevice) emulateAbs(key int, absInfo AbsInfo) {
+	setBit(dev.AbsBits[:], key)
+	dev.setAbsInfo(key, absInfo)
+
+	if dev.emulatedAbs == nil {
+		dev.emulatedAbs = make(map[int]bool)
+	}
+	dev.emulatedAbs[key] = true
+}
+
+// Order touch screens first, Type-B before Type-A before single-touch,
+// keeping scan order otherwise
+func sortDevices(devs []*InputDevice) {
+	rank := func(dev *InputDevice) int {
+		r := int(dev.Protocol)
+		if !dev.Direct {
+			r += 3
+		}
+		return r
+	}
+
+	sort.SliceStable(devs, func(i, j int) bool {
+		return rank(devs[i]) < rank(devs[j])
+	})
+}
+
+///----------Slot Emulation-----------///
+
+// Contact tracked by slottedSource
+type slottedContact struct {
+	Active     bool
+	TrackingId int32
+	Values     map[int]int32
+}
+
+// slottedSource EventSource turning Type-A and single-touch reports into
+// slotted Type-B events, so the readers consume every protocol alike
+type slottedSource struct {
+	EventSource
+
+	protocol TouchProtocol
+	contacts []slottedContact
+	report   map[int]int32
+	frame    []map[int]int32
+	touching bool
+	dropped  bool
+	nextId   int32
+	pending  []InputEvent
+}
+
+func newSlottedSource(src EventSource, dev *InputDevice) *slottedSource {
+	return &slottedSource{
+		EventSource: src,
+		protocol:    dev.Protocol,
+		contacts:    make([]slottedContact, dev.Slots),
+		report:      make(map[int]int32),
+	}
+}
+
+func (src *slottedSource) ReadEvent() (InputEvent, error) {
+	for len(src.pending) == 0 {
+		event, err := src.EventSource.ReadEvent()
+		if err != nil {
+			return InputEvent{}, err
+		}
+		src.pending = src.convert(event)
+	}
+
+	event := src.pending[0]
+	src.pending = src.pending[1:]
+	return event, nil
+}
+
+// Feed source event, returns slotted events it turned into
+func (src *slottedSource) convert(event InputEvent) []InputEvent {
+	switch event.Type {
+	case evSyn:
+		switch event.Code {
+		case synMtReport:
+			if len(src.report) > 0 {
+				src.frame = append(src.frame, src.report)
+				src.report = make(map[int]int32)
+			}
+			return nil
+		case synDropped:
+			// Readers lift every contact on their own, start over
+			for slot := range src.contacts {
+				src.contacts[slot] = slottedContact{}
+			}
+			src.dropped = true
+			src.frame = nil
+			return []InputEvent{event}
+		case synReport:
+			if src.dropped {
+				src.dropped = false
+				src.frame = nil
+				if src.protocol == PROTOCOLA {
+					src.report = make(map[int]int32)
+				}
+				return []InputEvent{event}
+			}
+
+			events := src.assign(event)
+			src.frame = nil
+			if src.protocol == PROTOCOLA {
+				src.report = make(map[int]int32)
+			}
+			return events
+		}
+		break
+	case evKey:
+		if event.Code == btnTouch {
+			src.touching = event.Value != 0
+		}
+		break
+	case evAbs:
+		if src.protocol == PROTOCOLSINGLE {
+			switch event.Code {
+			case absX:
+				src.report[absMtPositionX] = event.Value
+				break
+			case absY:
+				src.report[absMtPositionY] = event.Value
+				break
+			case absPressure:
+				src.report[absMtPressure] = event.Value
+				break
+			}
+			return nil
+		}
+
+		if int(event.Code) > absMtSlot && event.Code <= absMtToolY {
+			src.report[int(event.Code)] = event.Value
+		}
+		return nil
+	}
+
+	return []InputEvent{event}
+}
+
+// Match contacts of finished frame to slots and build events for every change
+func (src *slottedSource) assign(report InputEvent) []InputEvent {
+	frame := src.frame
+	if src.protocol == PROTOCOLSINGLE {
+		// Single-touch axes keep their values between frames
+		frame = nil
+		if src.touching {
+			frame = append(frame, src.report)
+		}
+	} else if len(src.report) > 0 {
+		// Last contact without trailing SYN_MT_REPORT
+		frame = append(frame, src.report)
+	}
+
+	next := make([]map[int]int32, len(src.contacts))
+	matched := make([]bool, len(frame))
+
+	// Same tracking id, when the driver reports one
+	for idx, values := range frame {
+		id, ok := values[absMtTrackingId]
+		if !ok {
+			continue
+		}
+		for slot, contact := range src.contacts {
+			if contact.Active && next[slot] == nil && contact.TrackingId == id {
+				next[slot] = values
+				matched[idx] = true
+				break
+			}
+		}
+	}
+
+	// Otherwise nearest contact of previous frame, closest pairs first
+	type pair struct {
+		idx  int
+		slot int
+		dist float64
+	}
+	var pairs []pair
+	for idx, values := range frame {
+		if _, ok := values[absMtTrackingId]; ok || matched[idx] {
+			continue
+		}
+		for slot, contact := range src.contacts {
+			if !contact.Active || next[slot] != nil {
+				continue
+			}
+
+			// Squares of full int32 spans don't fit int64
+			dx := float64(int64(values[absMtPositionX]) - int64(contact.Values[absMtPositionX]))
+			dy := float64(int64(values[absMtPositionY]) - int64(contact.Values[absMtPositionY]))
+			pairs = append(pairs, pair{idx, slot, dx*dx + dy*dy})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].dist < pairs[j].dist })
+
+	for _, p := range pairs {
+		if !matched[p.idx] && next[p.slot] == nil {
+			next[p.slot] = frame[p.idx]
+			matched[p.idx] = true
+		}
+	}
+
+	// New contacts take lowest free slots, extra ones get dropped
+	lifted := make([]bool, len(src.contacts))
+	for slot, contact := range src.contacts {
+		lifted[slot] = contact.Active && next[slot] == nil
+	}
+	for idx, values := range frame {
+		if matched[idx] {
+			continue
+		}
+		for slot, contact := range src.contacts {
+			if !contact.Active && next[slot] == nil {
+				next[slot] = values
+				break
+			}
+		}
+	}
+
+	var events []InputEvent
+	absEvent := func(code int, value int32) {
+		events = append(events, InputEvent{Time: report.Time, Type: evAbs, Code: uint16(code), Value: value})
+	}
+
+	for slot := range src.contacts {
+		contact := &src.contacts[slot]
+		values := next[slot]
+
+		if values == nil {
+			if lifted[slot] {
+				absEvent(absMtSlot, int32(slot))
+				absEvent(absMtTrackingId, -1)
+				*contact = slottedContact{}
+			}
+			continue
+		}
+
+		started := !contact.Active
+		if started {
+			contact.Active = true
+			contact.TrackingId = -1
+			contact.Values = make(map[int]int32)
+		}
+		if id, ok := values[absMtTrackingId]; ok {
+			contact.TrackingId = id
+		}
+
+		slotWritten := false
+		if started {
+			absEvent(absMtSlot, int32(slot))
+			absEvent(absMtTrackingId, src.nextId)
+			src.nextId = (src.nextId + 1) & 0xFFFF
+			slotWritten = true
+		}
+
+		for _, code := range resyncAxes {
+			value, ok := values[code]
+			if !ok {
+				continue
+			}
+			if old, had := contact.Values[code]; had && old == value && !started {
+				continue
+			}
+
+			if !slotWritten {
+				absEvent(absMtSlot, int32(slot))
+				slotWritten = true
+			}
+			absEvent(code, value)
+			contact.Values[code] = value
+		}
+	}
+
+	return append(events, report)
+}
@@ -0,0 +1,58 @@
+package touchsimulation
+
+import (
+	"testing"
+)
+
+func TestSlottedSourceWideRangeMatch(t *testing.T) {
+	dev := NewFakeTouchDevice("test_wide_a", map[int]AbsInfo{
+		absMtPositionX: {Minimum: -0x7fffffff, Maximum: 0x7fffffff},
+		absMtPositionY: {Maximum: 2339},
+	})
+	src := newSlottedSource(NewFakeSource(), dev)
+
+	// Position of each slot's contact after feeding events, -1 once lifted
+	positions := map[int32]int32{}
+	var slot int32
+	feed := func(events ...InputEvent) {
+		for _, event := range events {
+			for _, out := range src.convert(event) {
+				if out.Type != evAbs {
+					continue
+				}
+				switch out.Code {
+				case absMtSlot:
+					slot = out.Value
+					break
+				case absMtTrackingId:
+					if out.Value == -1 {
+						positions[slot] = -1
+					}
+					break
+				case absMtPositionX:
+					positions[slot] = out.Value
+					break
+				}
+			}
+		}
+	}
+	contact := func(x int32) []InputEvent {
+		return []InputEvent{
+			{Type: evAbs, Code: absMtPositionX, Value: x},
+			{Type: evAbs, Code: absMtPositionY, Value: 100},
+			{Type: evSyn, Code: synMtReport},
+		}
+	}
+	report := InputEvent{Type: evSyn, Code: synReport}
+
+	feed(append(append(contact(-2147483000), contact(0)...), report)...)
+	if positions[0] != -2147483000 || positions[1] != 0 {
+		t.Fatalf("first frame slots %v", positions)
+	}
+
+	// Nearest to the middle contact, its int32 difference to the left one would wrap to near zero
+	feed(append(contact(2147483000), report)...)
+	if positions[0] != -1 || positions[1] != 2147483000 {
+		t.Errorf("contact matched wrong slot, slots %v", positions)
+	}
+}
@@ -0,0 +1,113 @@
+package touchsimulation
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+///----------Touch Recording-----------///
+
+// Recordings are JSON-lines files: one RecordHeader line describing the
+// source device followed by one RecordedEvent line per raw input event.
+
+// RecordHeader Source device details stored at the start of a recording
+type RecordHeader struct {
+	Name     string          `json:"name"`
+	Version  int32           `json:"version"`
+	Slots    int32           `json:"slots"`
+	IID      InputID         `json:"id"`
+	AbsInfos map[int]AbsInfo `json:"abs"`
+}
+
+// RecordedEvent Raw input event with its kernel timestamp
+type RecordedEvent struct {
+	Sec   int64  `json:"sec"`
+	Usec  int64  `json:"usec"`
+	Type  uint16 `json:"type"`
+	Code  uint16 `json:"code"`
+	Value int32  `json:"value"`
+}
+
+// Recorder Writes raw input events of a device to a recording
+type Recorder struct {
+	mu  sync.Mutex
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewRecorder Start recording of given device by writing its header
+func NewRecorder(w io.Writer, dev *InputDevice) (*Recorder, error) {
+	buf := bufio.NewWriter(w)
+	rec := &Recorder{
+		buf: buf,
+		enc: json.NewEncoder(buf),
+	}
+
+	err := rec.enc.Encode(RecordHeader{
+		Name:     dev.Name,
+		Version:  dev.Version,
+		Slots:    dev.Slots,
+		IID:      dev.IID,
+		AbsInfos: dev.AbsInfos,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rec, nil
+}
+
+// Record Append input event
+func (rec *Recorder) Record(event InputEvent) error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	return rec.enc.Encode(RecordedEvent{
+		Sec:   int64(event.Time.Sec),
+		Usec:  int64(event.Time.Usec),
+		Type:  event.Type,
+		Code:  event.Code,
+		Value: event.Value,
+	})
+}
+
+// Flush Write buffered events out
+func (rec *Recorder) Flush() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	return rec.buf.Flush()
+}
+
+// StartRecording Record every event read from the touch device to w
+func (s *Simulator) StartRecording(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.touchStart {
+		return ErrNotStarted
+	}
+
+	rec, err := NewRecorder(w, s.touchDevice)
+	if err != nil {
+		return err
+	}
+
+	s.recorder = rec
+	return nil
+}
+
+// StopRecording Stop recording and flush what got recorded
+func (s *Simulator) StopRecording() error {
+	s.mu.Lock()
+	rec := s.recorder
+	s.recorder = nil
+	s.mu.Unlock()
+
+	if rec == nil {
+		return nil
+	}
+	return rec.Flush()
+}
@@ -0,0 +1,265 @@
+package touchsimulation
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"time"
+)
+
+///----------Touch Replay-----------///
+
+var ErrEmptyRecording = errors.New("recording has no events")
+
+// Shortest time a looped pass takes, keeps recordings without duration from spinning
+const replayMinPass = time.Second / 120
+
+// Recording Touch session loaded from a recording file
+type Recording struct {
+	Header RecordHeader
+	Events []RecordedEvent
+}
+
+// ReplayOptions Playback settings of Replay
+type ReplayOptions struct {
+	// Speed Playback speed multiplier, 2 plays twice as fast. Zero plays at original speed
+	Speed float64
+	// Loop Start over after the last event until the simulation stops
+	Loop bool
+}
+
+// Replayed contact state of one recorded slot
+type replayContact struct {
+	PosX    int32
+	PosY    int32
+	Active  bool
+	Down    bool
+	Restart bool
+	Changed bool
+}
+
+// LoadRecording Read recording written by Recorder
+func LoadRecording(r io.Reader) (*Recording, error) {
+	dec := json.NewDecoder(r)
+
+	rec := &Recording{}
+	err := dec.Decode(&rec.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		event := RecordedEvent{}
+		err = dec.Decode(&event)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rec.Events = append(rec.Events, event)
+	}
+
+	return rec, nil
+}
+
+// Pointer id used for contacts replayed from given recorded slot,
+// negative ids are kept apart from the ones used by Down/Move/Up callers
+func replayPointerId(slot int32) int {
+	return -1 - int(slot)
+}
+
+// Map value of a recorded axis onto the same axis of the touch device, clamped to its range
+func rescaleAxis(value int32, from, to AbsInfo) int32 {
+	if from.Maximum <= from.Minimum || to.Maximum <= to.Minimum {
+		return clampAxis(value, to)
+	}
+
+	offset := clamp64(int64(value), int64(from.Minimum), int64(from.Maximum)) - int64(from.Minimum)
+	span := int64(to.Maximum) - int64(to.Minimum)
+
+	return int32(int64(to.Minimum) + divRound(offset*span, int64(from.Maximum)-int64(from.Minimum)))
+}
+
+// Replay Play recorded touches as fake pointers, honoring the original timing.
+// Recorded coordinates are rescaled when the recording came from a panel with different ranges.
+func (s *Simulator) Replay(rec *Recording, opts ReplayOptions) error {
+	if len(rec.Events) == 0 {
+		return ErrEmptyRecording
+	}
+
+	speed := opts.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	s.mu.Lock()
+	stop := s.stopChannel
+	s.mu.Unlock()
+
+	for {
+		start := time.Now()
+		err := s.replayOnce(rec, speed)
+		if err != nil || !opts.Loop {
+			return err
+		}
+
+		//Pass took no time, all events share one timestamp
+		if wait := replayMinPass - time.Since(start); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-stop:
+				return ErrNotStarted
+			}
+		}
+	}
+}
+
+func (s *Simulator) replayOnce(rec *Recording, speed float64) error {
+	s.mu.Lock()
+	if err := s.checkRunning(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	stop := s.stopChannel
+	xFrom, xTo := rec.Header.AbsInfos[absMtPositionX], s.touchDevice.AbsInfos[absMtPositionX]
+	yFrom, yTo := rec.Header.AbsInfos[absMtPositionY], s.touchDevice.AbsInfos[absMtPositionY]
+	s.mu.Unlock()
+
+	contacts := make(map[int32]*replayContact)
+	defer s.liftReplayContacts(contacts)
+
+	var currSlot int32 = 0
+	var firstTime int64 = -1
+	start := time.Now()
+
+	for _, event := range rec.Events {
+		eventTime := event.Sec*1000000 + event.Usec
+		if firstTime < 0 {
+			firstTime = eventTime
+		}
+
+		offset := time.Duration(float64(eventTime-firstTime)/speed) * time.Microsecond
+		if wait := time.Until(start.Add(offset)); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-stop:
+				return ErrNotStarted
+			}
+		}
+
+		contact := contacts[currSlot]
+		if contact == nil {
+			contact = &replayContact{}
+			contacts[currSlot] = contact
+		}
+
+		switch event.Type {
+		case evSyn:
+			if event.Code == synReport {
+				err := s.applyReplayFrame(contacts)
+				if err != nil {
+					return err
+				}
+			}
+			break
+		case evAbs:
+			switch event.Code {
+			case absMtSlot:
+				currSlot = event.Value
+				break
+			case absMtTrackingId:
+				contact.Restart = contact.Active && event.Value != -1
+				contact.Active = event.Value != -1
+				contact.Changed = true
+				break
+			case absMtPositionX:
+				contact.PosX = rescaleAxis(event.Value, xFrom, xTo)
+				contact.Changed = true
+				break
+			case absMtPositionY:
+				contact.PosY = rescaleAxis(event.Value, yFrom, yTo)
+				contact.Changed = true
+				break
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// Turn changed replay contacts into one frame of fake pointer updates
+func (s *Simulator) applyReplayFrame(contacts map[int32]*replayContact) error {
+	slots := make([]int32, 0, len(contacts))
+	for slot := range contacts {
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i] < slots[j] })
+
+	s.mu.Lock()
+
+	if err := s.checkRunning(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	for _, slot := range slots {
+		contact := contacts[slot]
+		if !contact.Changed {
+			continue
+		}
+		contact.Changed = false
+
+		id := replayPointerId(slot)
+
+		if contact.Down && (!contact.Active || contact.Restart) {
+			_ = s.upPointer(id)
+			contact.Down = false
+		}
+		contact.Restart = false
+
+		if !contact.Active {
+			continue
+		}
+
+		if contact.Down {
+			_ = s.movePointer(id, contact.PosX, contact.PosY)
+		} else if s.downPointer(id, contact.PosX, contact.PosY) == nil {
+			contact.Down = true
+		}
+	}
+
+	s.mu.Unlock()
+
+	s.notify()
+	return nil
+}
+
+// Lift whatever is still down when replay ends
+func (s *Simulator) liftReplayContacts(contacts map[int32]*replayContact) {
+	s.mu.Lock()
+
+	if s.checkRunning() != nil {
+		s.mu.Unlock()
+		return
+	}
+
+	lifted := false
+	for slot, contact := range contacts {
+		if contact.Down {
+			_ = s.upPointer(replayPointerId(slot))
+			contact.Down = false
+			lifted = true
+		}
+	}
+
+	s.mu.Unlock()
+
+	if lifted {
+		s.notify()
+	}
+}
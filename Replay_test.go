@@ -0,0 +1,67 @@
+package touchsimulation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReplayEmptyRecording(t *testing.T) {
+	dev := newTestDevice()
+	s := NewSimulatorWithFactory(NewFakeFactory(dev))
+	if err := s.Start(TYPEB, 1080, 2340, dev); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	err := s.Replay(&Recording{}, ReplayOptions{Loop: true})
+	if !errors.Is(err, ErrEmptyRecording) {
+		t.Errorf("Replay of empty recording = %v, want ErrEmptyRecording", err)
+	}
+}
+
+func TestReplayLoopWithoutDuration(t *testing.T) {
+	dev := newTestDevice()
+	factory := NewFakeFactory(dev)
+	s := NewSimulatorWithFactory(factory)
+	if err := s.Start(TYPEB, 1080, 2340, dev); err != nil {
+		t.Fatal(err)
+	}
+
+	//Every event at the same time, a pass takes no time at all
+	rec := &Recording{
+		Header: RecordHeader{AbsInfos: dev.AbsInfos},
+		Events: []RecordedEvent{
+			{Type: evAbs, Code: absMtSlot, Value: 0},
+			{Type: evAbs, Code: absMtTrackingId, Value: 1},
+			{Type: evAbs, Code: absMtPositionX, Value: 100},
+			{Type: evAbs, Code: absMtPositionY, Value: 100},
+			{Type: evSyn, Code: synReport},
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Replay(rec, ReplayOptions{Loop: true})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	sink := factory.CurrentSink()
+	s.Stop()
+
+	if err := <-done; !errors.Is(err, ErrNotStarted) {
+		t.Errorf("looped Replay ended with %v, want ErrNotStarted", err)
+	}
+
+	// Down and lift frame per pass, at most one pass per replayMinPass
+	reports := 0
+	for _, event := range sink.Events() {
+		if event.Type == evSyn && event.Code == synReport {
+			reports++
+		}
+	}
+	passes := reports / 2
+	if limit := int(50*time.Millisecond/replayMinPass) * 2; passes > limit {
+		t.Errorf("%d passes in 50ms, want at most %d", passes, limit)
+	}
+}
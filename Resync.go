@@ -0,0 +1,140 @@
+package touchsimulation
+
+import (
+	"errors"
+)
+
+///----------SYN_DROPPED Recovery-----------///
+
+// StateSource EventSource able to report current device state, used to
+// rebuild contact tables once the kernel dropped events (SYN_DROPPED)
+type StateSource interface {
+	// SlotValues Fetch current value of MT axis for each slot
+	SlotValues(code int, slots int32) ([]int32, error)
+	// AbsValue Fetch current value of Abs axis
+	AbsValue(code int) (int32, error)
+	// KeyState Determine if key is currently pressed
+	KeyState(code int) (bool, error)
+}
+
+// Per-slot axes restored by resync, in the order a driver reports them
+var resyncAxes = []int{
+	absMtPositionX,
+	absMtPositionY,
+	absMtTouchMajor,
+	absMtTouchMinor,
+	absMtWidthMajor,
+	absMtWidthMinor,
+	absMtOrientation,
+	absMtPressure,
+}
+
+// Discard events after SYN_DROPPED until the next SYN_REPORT, then replay
+// queried device state through handle so the frame carries the correction
+func (s *Simulator) handleDropped(inputEvent InputEvent, handle func(InputEvent) bool) bool {
+	if inputEvent.Type != evSyn || inputEvent.Code != synReport {
+		return false
+	}
+
+	s.synDropped = false
+
+	for _, event := range s.resyncEvents() {
+		handle(event)
+	}
+
+	return true
+}
+
+// Build events taking contact tables from their current state to the device's one.
+// Without a StateSource, or when querying it fails, every real contact gets lifted so nothing
+// stays stuck down; the failure is kept for Err until a later resync works.
+func (s *Simulator) resyncEvents() []InputEvent {
+	var events []InputEvent
+
+	absEvent := func(code int, value int32) {
+		events = append(events, InputEvent{Type: evAbs, Code: uint16(code), Value: value})
+	}
+
+	slots := s.touchDevice.Slots
+	trackingIds := make([]int32, slots)
+	for idx := range trackingIds {
+		trackingIds[idx] = -1
+	}
+	values := make(map[int][]int32)
+	currSlot := s.currSlot
+
+	if state, ok := s.source.(StateSource); ok {
+		err := s.queryState(state, trackingIds, values, &currSlot)
+		if err != nil {
+			s.readErr = &DeviceError{Kind: ErrResyncFailed, Path: s.touchDevice.Path, Err: err}
+			for idx := range trackingIds {
+				trackingIds[idx] = -1
+			}
+			values = nil
+		} else if errors.Is(s.readErr, ErrResyncFailed) {
+			s.readErr = nil
+		}
+	}
+
+	for slot := int32(0); slot < slots; slot++ {
+		trackingId := trackingIds[slot]
+		if trackingId == -1 && s.sourceIds[slot] == -1 {
+			continue
+		}
+
+		absEvent(absMtSlot, slot)
+
+		if trackingId != s.sourceIds[slot] {
+			absEvent(absMtTrackingId, trackingId)
+		}
+
+		if trackingId != -1 {
+			for _, code := range resyncAxes {
+				if axis, ok := values[code]; ok {
+					absEvent(code, axis[slot])
+				}
+			}
+		}
+	}
+
+	absEvent(absMtSlot, currSlot)
+	return events
+}
+
+// Fetch tracking ids, axis values and current slot from the device
+func (s *Simulator) queryState(state StateSource, trackingIds []int32, values map[int][]int32, currSlot *int32) error {
+	touching, err := state.KeyState(btnTouch)
+	if err != nil {
+		return err
+	}
+	if !touching && s.touchDevice.KeyBits != nil && hasSpecificKey(s.touchDevice.KeyBits, btnTouch) {
+		// Nothing touches the panel, stale slot values don't matter
+		return nil
+	}
+
+	ids, err := state.SlotValues(absMtTrackingId, s.touchDevice.Slots)
+	if err != nil {
+		return err
+	}
+	copy(trackingIds, ids)
+
+	for _, code := range resyncAxes {
+		if !s.touchDevice.hasAbs(code) {
+			continue
+		}
+
+		axis, err := state.SlotValues(code, s.touchDevice.Slots)
+		if err != nil {
+			return err
+		}
+		values[code] = axis
+	}
+
+	slot, err := state.AbsValue(absMtSlot)
+	if err != nil {
+		return err
+	}
+	*currSlot = slot
+
+	return nil
+}
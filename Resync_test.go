@@ -0,0 +1,46 @@
+package touchsimulation
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+)
+
+// StateSource whose key queries fail, like after the device went away
+type failingStateSource struct {
+	*FakeSource
+	err error
+}
+
+func (src *failingStateSource) KeyState(code int) (bool, error) {
+	return false, src.err
+}
+
+func TestResyncFailureSurfacesInErr(t *testing.T) {
+	s, _ := newSteppedSimulator(TYPEB, newTestDevice())
+	state := s.source.(*FakeSource)
+
+	//Contact down before events got dropped
+	s.handleEventB(InputEvent{Type: evAbs, Code: absMtTrackingId, Value: 5})
+	s.handleEventB(InputEvent{Type: evSyn, Code: synReport})
+
+	s.source = &failingStateSource{FakeSource: state, err: syscall.EIO}
+	s.handleEventB(InputEvent{Type: evSyn, Code: synDropped})
+	s.handleEventB(InputEvent{Type: evSyn, Code: synReport})
+
+	err := s.Err()
+	if !errors.Is(err, ErrResyncFailed) || !errors.Is(err, syscall.EIO) {
+		t.Fatalf("Err() = %v, want ErrResyncFailed wrapping EIO", err)
+	}
+	if s.sourceIds[0] != -1 {
+		t.Errorf("contact stayed down after failed resync, tracking id %d", s.sourceIds[0])
+	}
+
+	s.source = state
+	s.handleEventB(InputEvent{Type: evSyn, Code: synDropped})
+	s.handleEventB(InputEvent{Type: evSyn, Code: synReport})
+
+	if err := s.Err(); err != nil {
+		t.Errorf("Err() = %v after working resync, want nil", err)
+	}
+}
@@ -0,0 +1,108 @@
+package touchsimulation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+///----------Device Selection-----------///
+
+// DeviceFilter Criteria picking touch device among scanned ones, zero fields match any device
+type DeviceFilter struct {
+	// Path Event node, like /dev/input/event3
+	Path string
+	// Name Pattern matched against device name
+	Name *regexp.Regexp
+	// Vendor Vendor id from InputID
+	Vendor uint16
+	// Product Product id from InputID
+	Product uint16
+}
+
+// Match Determine if device fulfills every criteria of filter
+func (filter DeviceFilter) Match(dev *InputDevice) bool {
+	if filter.Path != "" && filepath.Clean(filter.Path) != dev.Path {
+		return false
+	}
+	if filter.Name != nil && !filter.Name.MatchString(dev.Name) {
+		return false
+	}
+	if filter.Vendor != 0 && filter.Vendor != dev.IID.Vendor {
+		return false
+	}
+	if filter.Product != 0 && filter.Product != dev.IID.Product {
+		return false
+	}
+	return true
+}
+
+// SelectDevices Fetch devices matching filter, keeping their order
+func SelectDevices(devs []*InputDevice, filter DeviceFilter) []*InputDevice {
+	var selected []*InputDevice
+
+	for _, dev := range devs {
+		if filter.Match(dev) {
+			selected = append(selected, dev)
+		}
+	}
+	return selected
+}
+
+// PromptDevice List devices on w and read number of the chosen one from r
+func PromptDevice(devs []*InputDevice, r io.Reader, w io.Writer) (*InputDevice, error) {
+	if len(devs) < 1 {
+		return nil, ErrNoDevice
+	}
+
+	for i, dev := range devs {
+		_, _ = fmt.Fprintf(w, "[%d] %s: %s (%04x:%04x, %s)\n", i, dev.Path, dev.Name, dev.IID.Vendor, dev.IID.Product, dev.Protocol)
+	}
+
+	reader := bufio.NewReader(r)
+	for {
+		_, _ = fmt.Fprintf(w, "Select touch device [0-%d]: ", len(devs)-1)
+
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return nil, err
+		}
+
+		idx, convErr := strconv.Atoi(strings.TrimSpace(line))
+		if convErr == nil && idx >= 0 && idx < len(devs) {
+			return devs[idx], nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Order event node paths by their number, so event10 comes after event2
+func sortEventPaths(paths []string) {
+	eventNum := func(path string) int {
+		num, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(path), "event"))
+		if err != nil {
+			return -1
+		}
+		return num
+	}
+
+	sort.SliceStable(paths, func(i, j int) bool {
+		return eventNum(paths[i]) < eventNum(paths[j])
+	})
+}
+
+// CloseDevices Close files of scanned devices other than keep, nil closes all of them
+func CloseDevices(devs []*InputDevice, keep *InputDevice) {
+	for _, dev := range devs {
+		if dev != keep && dev.File != nil {
+			_ = dev.File.Close()
+		}
+	}
+}
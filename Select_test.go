@@ -0,0 +1,170 @@
+package touchsimulation
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDeviceFilterMatch(t *testing.T) {
+	dev := &InputDevice{
+		Name: "fts_ts",
+		Path: "/dev/input/event3",
+		IID:  InputID{Vendor: 0x1234, Product: 0x5678},
+	}
+
+	tests := []struct {
+		name   string
+		filter DeviceFilter
+		want   bool
+	}{
+		{"empty filter", DeviceFilter{}, true},
+		{"same path", DeviceFilter{Path: "/dev/input/event3"}, true},
+		{"uncleaned path", DeviceFilter{Path: "/dev/input/../input//event3"}, true},
+		{"other path", DeviceFilter{Path: "/dev/input/event30"}, false},
+		{"name pattern", DeviceFilter{Name: regexp.MustCompile("^fts")}, true},
+		{"other name", DeviceFilter{Name: regexp.MustCompile("^goodix")}, false},
+		{"vendor only", DeviceFilter{Vendor: 0x1234}, true},
+		{"product only", DeviceFilter{Product: 0x5678}, true},
+		{"vendor and product", DeviceFilter{Vendor: 0x1234, Product: 0x5678}, true},
+		{"other vendor", DeviceFilter{Vendor: 0x4321}, false},
+		{"other product", DeviceFilter{Vendor: 0x1234, Product: 0x8765}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.filter.Match(dev); got != test.want {
+				t.Errorf("Match = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSelectDevicesKeepsOrder(t *testing.T) {
+	a := &InputDevice{Name: "touch", Path: "/dev/input/event1"}
+	b := &InputDevice{Name: "keys", Path: "/dev/input/event2"}
+	c := &InputDevice{Name: "touch", Path: "/dev/input/event3"}
+
+	selected := SelectDevices([]*InputDevice{a, b, c}, DeviceFilter{Name: regexp.MustCompile("^touch$")})
+	if !reflect.DeepEqual(selected, []*InputDevice{a, c}) {
+		t.Errorf("selected %v, want event1 and event3", selected)
+	}
+	if selected := SelectDevices([]*InputDevice{a, b, c}, DeviceFilter{Vendor: 1}); len(selected) != 0 {
+		t.Errorf("selected %d devices, want none", len(selected))
+	}
+}
+
+func TestSortEventPaths(t *testing.T) {
+	paths := []string{
+		"/dev/input/event10",
+		"/dev/input/event2",
+		"/dev/input/mice",
+		"/dev/input/event1",
+		"/dev/input/js0",
+	}
+	sortEventPaths(paths)
+
+	want := []string{
+		"/dev/input/mice",
+		"/dev/input/js0",
+		"/dev/input/event1",
+		"/dev/input/event2",
+		"/dev/input/event10",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("sorted %v, want %v", paths, want)
+	}
+}
+
+func TestSortDevices(t *testing.T) {
+	devs := []*InputDevice{
+		{Name: "indirect B", Protocol: PROTOCOLB},
+		{Name: "direct single", Protocol: PROTOCOLSINGLE, Direct: true},
+		{Name: "direct A", Protocol: PROTOCOLA, Direct: true},
+		{Name: "direct B", Protocol: PROTOCOLB, Direct: true},
+		{Name: "indirect A", Protocol: PROTOCOLA},
+		{Name: "direct B 2", Protocol: PROTOCOLB, Direct: true},
+	}
+	sortDevices(devs)
+
+	var names []string
+	for _, dev := range devs {
+		names = append(names, dev.Name)
+	}
+
+	want := []string{"direct B", "direct B 2", "direct A", "direct single", "indirect B", "indirect A"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("sorted %v, want %v", names, want)
+	}
+}
+
+func TestPromptDevice(t *testing.T) {
+	devs := []*InputDevice{
+		{Name: "first", Path: "/dev/input/event1"},
+		{Name: "second", Path: "/dev/input/event2"},
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    *InputDevice
+		wantErr error
+		prompts int
+	}{
+		{"first line", "0\n", devs[0], nil, 1},
+		{"last line without newline", "1", devs[1], nil, 1},
+		{"out of range then valid", "2\n-1\n1\n", devs[1], nil, 3},
+		{"garbage then valid", "second\n\n 0 \n", devs[0], nil, 3},
+		{"no input", "", nil, io.EOF, 1},
+		{"no valid line", "5\nabc", nil, io.EOF, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := &strings.Builder{}
+
+			got, err := PromptDevice(devs, strings.NewReader(test.input), out)
+			if got != test.want || !errors.Is(err, test.wantErr) {
+				t.Errorf("PromptDevice = %v, %v, want %v, %v", got, err, test.want, test.wantErr)
+			}
+			if prompts := strings.Count(out.String(), "Select touch device [0-1]"); prompts != test.prompts {
+				t.Errorf("prompted %d times, want %d", prompts, test.prompts)
+			}
+		})
+	}
+
+	if _, err := PromptDevice(nil, strings.NewReader("0\n"), io.Discard); !errors.Is(err, ErrNoDevice) {
+		t.Errorf("PromptDevice without devices = %v, want ErrNoDevice", err)
+	}
+}
+
+func TestCloseDevices(t *testing.T) {
+	open := func() *InputDevice {
+		file, err := os.Create(filepath.Join(t.TempDir(), "event"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &InputDevice{File: file}
+	}
+	isOpen := func(dev *InputDevice) bool {
+		_, err := dev.File.Stat()
+		return err == nil
+	}
+
+	devs := []*InputDevice{open(), open(), {}, open()}
+
+	CloseDevices(devs, devs[1])
+	if isOpen(devs[0]) || !isOpen(devs[1]) || isOpen(devs[3]) {
+		t.Error("only the kept device should stay open")
+	}
+
+	CloseDevices(devs, nil)
+	if isOpen(devs[1]) {
+		t.Error("nil keep should close every device")
+	}
+}
@@ -0,0 +1,125 @@
+package touchsimulation
+
+///----------Fake Contact Shape-----------///
+
+// ContactShape Size, orientation and pressure of a fake contact in touch device units,
+// axes the device doesn't report get ignored
+type ContactShape struct {
+	TouchMajor  int32
+	TouchMinor  int32
+	WidthMajor  int32
+	WidthMinor  int32
+	Orientation int32
+	Pressure    int32
+}
+
+// Share of axis range a resting fingertip covers
+const (
+	shapeMajorShare    = 0.14
+	shapeMinorShare    = 0.10
+	shapePressureShare = 0.35
+)
+
+// Value at share of axis range above its minimum
+func axisShare(info AbsInfo, share float32) int32 {
+	return info.Minimum + int32(float32(info.Maximum-info.Minimum)*share)
+}
+
+// DeviceShape Shape of a resting fingertip on device: contact sizes and pressure at fixed
+// shares of their axis ranges, upright orientation
+func DeviceShape(dev *InputDevice) ContactShape {
+	shape := ContactShape{}
+
+	if dev.hasTouchMajor {
+		shape.TouchMajor = axisShare(dev.AbsInfos[absMtTouchMajor], shapeMajorShare)
+	}
+	if dev.hasTouchMinor {
+		shape.TouchMinor = axisShare(dev.AbsInfos[absMtTouchMinor], shapeMinorShare)
+	}
+	if dev.hasWidthMajor {
+		shape.WidthMajor = axisShare(dev.AbsInfos[absMtWidthMajor], shapeMajorShare)
+	}
+	if dev.hasWidthMinor {
+		shape.WidthMinor = axisShare(dev.AbsInfos[absMtWidthMinor], shapeMinorShare)
+	}
+	if dev.hasOrientation {
+		// Zero is upright for signed ranges, the lowest value otherwise
+		shape.Orientation = clampAxis(0, dev.AbsInfos[absMtOrientation])
+	}
+	if dev.hasPressure {
+		shape.Pressure = axisShare(dev.AbsInfos[absMtPressure], shapePressureShare)
+	}
+
+	return shape
+}
+
+// Clamp every axis of shape into device's ranges
+func (dev *InputDevice) clampShape(shape ContactShape) ContactShape {
+	return ContactShape{
+		TouchMajor:  clampAxis(shape.TouchMajor, dev.AbsInfos[absMtTouchMajor]),
+		TouchMinor:  clampAxis(shape.TouchMinor, dev.AbsInfos[absMtTouchMinor]),
+		WidthMajor:  clampAxis(shape.WidthMajor, dev.AbsInfos[absMtWidthMajor]),
+		WidthMinor:  clampAxis(shape.WidthMinor, dev.AbsInfos[absMtWidthMinor]),
+		Orientation: clampAxis(shape.Orientation, dev.AbsInfos[absMtOrientation]),
+		Pressure:    clampAxis(shape.Pressure, dev.AbsInfos[absMtPressure]),
+	}
+}
+
+// WithShape Same op, also changing contact shape of its pointer; ignored by up ops
+func (op PointerOp) WithShape(shape ContactShape) PointerOp {
+	op.Shape = &shape
+	return op
+}
+
+// Change shape of fake pointer id, lock must be held
+func (s *Simulator) shapePointer(id int, shape ContactShape) error {
+	ptr, ok := s.fakePointers[id]
+	if !ok {
+		return ErrPointerUp
+	}
+
+	ptr.Shape = s.touchDevice.clampShape(shape)
+	s.writeFakeContact(ptr)
+	return nil
+}
+
+// DefaultShape Shape fake pointers touch down with, derived from the touch device unless set
+func (s *Simulator) DefaultShape() ContactShape {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.fakeShape
+}
+
+// SetDefaultShape Change shape of fake pointers touching down from now on, kept across restarts
+func (s *Simulator) SetDefaultShape(shape ContactShape) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.touchDevice != nil {
+		shape = s.touchDevice.clampShape(shape)
+	}
+	s.fakeShape = shape
+	s.fakeShapeSet = true
+}
+
+// SetShape Change shape of fake pointer id, like to ramp its pressure up or roll it
+func (s *Simulator) SetShape(id int, shape ContactShape) error {
+	s.mu.Lock()
+
+	if err := s.checkRunning(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	err := s.shapePointer(id, shape)
+
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	s.notify()
+	return nil
+}
@@ -0,0 +1,66 @@
+package touchsimulation
+
+import (
+	"testing"
+)
+
+func TestDeviceShape(t *testing.T) {
+	dev := NewFakeTouchDevice("test_shape", map[int]AbsInfo{
+		absMtSlot:        {Maximum: 9},
+		absMtTouchMajor:  {Minimum: 10, Maximum: 110},
+		absMtOrientation: {Minimum: 0, Maximum: 15},
+		absMtPositionX:   {Maximum: 1079},
+		absMtPositionY:   {Maximum: 2339},
+		absMtTrackingId:  {Maximum: 65535},
+		absMtPressure:    {Maximum: 1000},
+	})
+
+	want := ContactShape{TouchMajor: 24, Orientation: 0, Pressure: 350}
+	if got := DeviceShape(dev); got != want {
+		t.Errorf("DeviceShape = %+v, want %+v", got, want)
+	}
+
+	if got := DeviceShape(newTypeATestDevice()); got.Orientation != 0 || got.TouchMinor != 0 {
+		t.Errorf("DeviceShape of device without minor axes = %+v", got)
+	}
+}
+
+func TestShapeChanges(t *testing.T) {
+	s, sink := newSteppedSimulator(TYPEB, newTestDevice())
+
+	pressIn := ContactShape{TouchMajor: 20, WidthMajor: 20, Orientation: -10, Pressure: 40}
+	if err := s.Frame(DownOp(0, 540, 1170).WithShape(pressIn)); err != nil {
+		t.Fatal(err)
+	}
+	<-s.syncChannel
+	s.dispatchB()
+
+	// Out of range pressure gets clamped to the axis
+	if err := s.SetShape(0, ContactShape{TouchMajor: 36, WidthMajor: 36, Orientation: 30, Pressure: 900}); err != nil {
+		t.Fatal(err)
+	}
+	<-s.syncChannel
+	s.dispatchB()
+
+	values := map[uint16][]int32{}
+	for _, event := range sink.Events() {
+		if event.Type == evAbs {
+			values[event.Code] = append(values[event.Code], event.Value)
+		}
+	}
+
+	for code, want := range map[uint16][]int32{
+		absMtPressure:    {40, 255},
+		absMtOrientation: {-10, 30},
+		absMtTouchMajor:  {20, 36},
+	} {
+		got := values[code]
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("%s values %v, want %v", eventCodeNames[evAbs][code], got, want)
+		}
+	}
+
+	if err := s.SetShape(1, ContactShape{}); err != ErrPointerUp {
+		t.Errorf("shaping unknown pointer: got %v, want ErrPointerUp", err)
+	}
+}
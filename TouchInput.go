@@ -1,10 +1,13 @@
-package main
+// Package touchsimulation simulates touch input on Android(Linux) devices by
+// bridging a real touch screen through a virtual UInput device.
+package touchsimulation
 
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
-	"os"
+	"sync"
 	"syscall"
 	"time"
 	"unsafe"
@@ -20,35 +23,72 @@ const (
 	TYPEB
 )
 
-const (
-	fakeContact = 9
+var (
+	ErrNotStarted        = errors.New("simulation is not started")
+	ErrNoFreeSlot        = errors.New("no free touch slot")
+	ErrPointerDown       = errors.New("fake pointer is already down")
+	ErrPointerUp         = errors.New("fake pointer is not down")
+	ErrNoDevice          = errors.New("no touch device found")
+	ErrUinputUnavailable = errors.New("uinput is unavailable")
+	ErrGrabFailed        = errors.New("touch device grab failed")
+	ErrDeviceLost        = errors.New("touch device is gone, waiting for it to return")
+	ErrResyncFailed      = errors.New("touch state resync after dropped events failed")
 )
 
-var (
+// Simulator Bridges one touch device to its UInput clone and injects fake touches
+type Simulator struct {
 	currMode TypeMode
 
-	touchSend  = false
-	touchStart = false
+	touchStart bool
 
-	displayWidth  int32
-	displayHeight int32
+	transform Transform
 
-	fakeTouchMajor  int32 = -1
-	fakeTouchMinor  int32 = -1
-	fakeWidthMajor  int32 = -1
-	fakeWidthMinor  int32 = -1
-	fakeOrientation int32 = -1
-	fakePressure    int32 = -1
+	fakeShape    ContactShape
+	fakeShapeSet bool
 
 	syncChannel chan bool
 	stopChannel chan bool
 
+	factory  DeviceFactory
+	source   EventSource
+	sink     EventSink
+	recorder *Recorder
+	readErr  error
+
 	touchDevice *InputDevice
-	uInputTouch *InputDevice
 
 	touchContactsA []TouchContactA
 	touchContactsB []TouchContactB
-)
+
+	currSlot   int32
+	sourceIds  []int32
+	synDropped bool
+	isBtnDown  bool
+
+	lost          bool
+	autoRebridge  bool
+	statusHandler func(message string)
+
+	fakePointers   map[int]*FakePointer
+	lastTrackingId int32
+
+	mu    sync.Mutex
+	runMu sync.Mutex
+	wg    sync.WaitGroup
+}
+
+// NewSimulator Create new idle Simulator working on evdev and uinput devices
+func NewSimulator() *Simulator {
+	return NewSimulatorWithFactory(EvdevFactory{})
+}
+
+// NewSimulatorWithFactory Create new idle Simulator working on devices of given factory
+func NewSimulatorWithFactory(factory DeviceFactory) *Simulator {
+	return &Simulator{
+		factory:      factory,
+		autoRebridge: true,
+	}
+}
 
 ///----------Touch Contacts-----------///
 
@@ -86,22 +126,26 @@ type TouchContactB struct {
 
 ///----------Touch Management Interface-----------///
 
-// Read Input Event from Input Device
-func readInputEvent(f *os.File) (InputEvent, error) {
-	event := InputEvent{}
-	buffer := make([]byte, unsafe.Sizeof(InputEvent{}))
+// Size of one InputEvent as written by the kernel
+const inputEventSize = int(unsafe.Sizeof(InputEvent{}))
 
-	_, err := f.Read(buffer)
-	if err != nil {
-		return event, err
+// Decode batch of Input Events read from Input Device
+func decodeInputEvents(buffer []byte) ([]InputEvent, error) {
+	if len(buffer)%inputEventSize != 0 {
+		return nil, fmt.Errorf("partial input event read: %d bytes", len(buffer))
 	}
 
-	err = binary.Read(bytes.NewBuffer(buffer), binary.LittleEndian, &event)
-	if err != nil {
-		return event, err
+	events := make([]InputEvent, len(buffer)/inputEventSize)
+	reader := bytes.NewReader(buffer)
+
+	for i := range events {
+		err := binary.Read(reader, binary.LittleEndian, &events[i])
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return event, nil
+	return events, nil
 }
 
 func inputEventToBytes(event InputEvent) []byte {
@@ -110,9 +154,9 @@ func inputEventToBytes(event InputEvent) []byte {
 	return buf.Bytes()
 }
 
-// Write Input Event to Specified Fd
-func writeEvent(f *os.File, Type, Code uint16, Value int32) {
-	_, _ = f.Write(inputEventToBytes(InputEvent{
+// Write Input Event to Specified Sink
+func writeEvent(sink EventSink, Type, Code uint16, Value int32) {
+	_ = sink.WriteEvent(InputEvent{
 		Time: syscall.Timeval{
 			Sec:  0,
 			Usec: 0,
@@ -120,632 +164,1058 @@ func writeEvent(f *os.File, Type, Code uint16, Value int32) {
 		Type:  Type,
 		Code:  Code,
 		Value: Value,
-	}))
+	})
 }
 
 // Reading Touch Inputs from TypeA event
-func eventReaderA() {
-	var currSlot int32 = 0
+func (s *Simulator) eventReaderA() {
+	defer s.wg.Done()
 
 	fmt.Printf("-------------------------------------\n")
 
 	for {
-		select {
-		case <-stopChannel:
+		inputEvent, err := s.source.ReadEvent()
+		if err != nil {
+			s.readerFailed(err)
 			return
-		default:
 		}
 
-		inputEvent, err := readInputEvent(touchDevice.File)
-		if err != nil {
-			fmt.Printf("input read error\n")
-			break
-		}
-
-		hasSyn := false
-
-		switch inputEvent.Type {
-		case evSyn:
-			if inputEvent.Code == synReport {
-				hasSyn = true
-				fmt.Printf("SYN_REPORT\n")
-			}
-			break
-		case evKey:
-			if inputEvent.Code == btnTouch {
-				touchType := "UP"
-				if inputEvent.Value == 1 {
-					touchType = "DOWN"
-				}
-				fmt.Printf("BTN_TOUCH: %s\n", touchType)
-			}
-			break
-		case evAbs:
-			switch inputEvent.Code {
-			case absMtSlot:
-				currSlot = inputEvent.Value
-				fmt.Printf("ABS_MT_SLOT: %d\n", inputEvent.Value)
-				break
-			case absMtTrackingId:
-				touchContactsA[currSlot].Active = inputEvent.Value != -1
-				fmt.Printf("ABS_MT_TRACKING_ID: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtPositionX:
-				touchContactsA[currSlot].PosX = inputEvent.Value
-				fmt.Printf("ABS_MT_POSITION_X: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtPositionY:
-				touchContactsA[currSlot].PosY = inputEvent.Value
-				fmt.Printf("ABS_MT_POSITION_Y: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			}
-			break
+		s.mu.Lock()
+		if s.recorder != nil {
+			_ = s.recorder.Record(inputEvent)
 		}
+		hasSyn := s.handleEventA(inputEvent)
+		s.mu.Unlock()
 
 		if hasSyn {
-			syncChannel <- true
+			s.notify()
 			fmt.Printf("-------------------------------------\n")
 		}
 	}
 }
 
 // Writing Touch Inputs to TypeA event
-func eventDispatcherA() {
-	var isBtnDown bool = false
+func (s *Simulator) eventDispatcherA() {
+	defer s.wg.Done()
 
 	for {
 		select {
-		case <-stopChannel:
+		case <-s.stopChannel:
 			return
-		default:
+		case <-s.syncChannel:
+			s.mu.Lock()
+			s.dispatchA()
+			s.mu.Unlock()
 		}
+	}
+}
 
-		select {
-		case <-syncChannel:
-			{
-				nextSlot := 0
-
-				for idx, contact := range touchContactsA {
-					if contact.Active && contact.PosX > 0 && contact.PosY > 0 {
-						writeEvent(uInputTouch.File, evAbs, absMtPositionX, contact.PosX)
-						writeEvent(uInputTouch.File, evAbs, absMtPositionY, contact.PosY)
-						writeEvent(uInputTouch.File, evAbs, absMtTrackingId, int32(idx))
-						writeEvent(uInputTouch.File, evSyn, synMtReport, 0)
-
-						nextSlot++
-					}
-				}
+// Update Type-A contact tables from source event, reports whether frame got completed
+func (s *Simulator) handleEventA(inputEvent InputEvent) bool {
+	hasSyn := false
 
-				if nextSlot == 0 && isBtnDown { //Button Up
-					isBtnDown = false
-					writeEvent(uInputTouch.File, evSyn, synMtReport, 0)
-					writeEvent(uInputTouch.File, evKey, btnTouch, 0)
-				} else if nextSlot > 0 && !isBtnDown { //Button Down
-					isBtnDown = true
-					writeEvent(uInputTouch.File, evKey, btnTouch, 1)
-				}
+	if s.synDropped {
+		return s.handleDropped(inputEvent, s.handleEventA)
+	}
 
-				writeEvent(uInputTouch.File, evSyn, synReport, 0)
+	switch inputEvent.Type {
+	case evSyn:
+		if inputEvent.Code == synReport {
+			hasSyn = true
+			fmt.Printf("SYN_REPORT\n")
+		} else if inputEvent.Code == synDropped {
+			s.synDropped = true
+			fmt.Printf("SYN_DROPPED\n")
+		}
+		break
+	case evKey:
+		if inputEvent.Code == btnTouch {
+			touchType := "UP"
+			if inputEvent.Value == 1 {
+				touchType = "DOWN"
 			}
-		default:
+			fmt.Printf("BTN_TOUCH: %s\n", touchType)
 		}
+		break
+	case evAbs:
+		switch inputEvent.Code {
+		case absMtSlot:
+			s.currSlot = inputEvent.Value
+			fmt.Printf("ABS_MT_SLOT: %d\n", inputEvent.Value)
+			break
+		case absMtTrackingId:
+			s.sourceIds[s.currSlot] = inputEvent.Value
+			if inputEvent.Value != -1 {
+				s.claimSlot(s.currSlot)
+				s.touchContactsA[s.currSlot].Active = true
+			} else if s.slotOwner(s.currSlot) == nil {
+				s.touchContactsA[s.currSlot].Active = false
+			}
+			fmt.Printf("ABS_MT_TRACKING_ID: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		case absMtPositionX:
+			if s.slotOwner(s.currSlot) == nil {
+				s.touchContactsA[s.currSlot].PosX = inputEvent.Value
+			}
+			fmt.Printf("ABS_MT_POSITION_X: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		case absMtPositionY:
+			if s.slotOwner(s.currSlot) == nil {
+				s.touchContactsA[s.currSlot].PosY = inputEvent.Value
+			}
+			fmt.Printf("ABS_MT_POSITION_Y: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		}
+		break
 	}
+
+	return hasSyn
+}
+
+// Write one frame of contact tables as TypeA events
+func (s *Simulator) dispatchA() {
+	if s.sink == nil {
+		return
+	}
+
+	nextSlot := 0
+
+	for idx, contact := range s.touchContactsA {
+		if contact.Active && contact.PosX > 0 && contact.PosY > 0 {
+			writeEvent(s.sink, evAbs, absMtPositionX, contact.PosX)
+			writeEvent(s.sink, evAbs, absMtPositionY, contact.PosY)
+			writeEvent(s.sink, evAbs, absMtTrackingId, int32(idx))
+			writeEvent(s.sink, evSyn, synMtReport, 0)
+
+			nextSlot++
+		}
+	}
+
+	if nextSlot == 0 && s.isBtnDown { //Button Up
+		s.isBtnDown = false
+		writeEvent(s.sink, evSyn, synMtReport, 0)
+		writeEvent(s.sink, evKey, btnTouch, 0)
+	} else if nextSlot > 0 && !s.isBtnDown { //Button Down
+		s.isBtnDown = true
+		writeEvent(s.sink, evKey, btnTouch, 1)
+	}
+
+	writeEvent(s.sink, evSyn, synReport, 0)
 }
 
 // Reading Touch Inputs from TypeB event
-func eventReaderB() {
-	var currSlot int32 = 0
+func (s *Simulator) eventReaderB() {
+	defer s.wg.Done()
 
 	fmt.Printf("-------------------------------------\n")
 
 	for {
-		select {
-		case <-stopChannel:
+		inputEvent, err := s.source.ReadEvent()
+		if err != nil {
+			s.readerFailed(err)
 			return
-		default:
 		}
 
-		inputEvent, err := readInputEvent(touchDevice.File)
-		if err != nil {
-			fmt.Printf("input read error\n")
-			break
+		s.mu.Lock()
+		if s.recorder != nil {
+			_ = s.recorder.Record(inputEvent)
+		}
+		hasSyn := s.handleEventB(inputEvent)
+		s.mu.Unlock()
+
+		if hasSyn {
+			s.notify()
+			fmt.Printf("-------------------------------------\n")
+		}
+	}
+}
+
+// Writing Touch Inputs to TypeB device
+func (s *Simulator) eventDispatcherB() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.stopChannel:
+			return
+		case <-s.syncChannel:
+			s.mu.Lock()
+			s.dispatchB()
+			s.mu.Unlock()
 		}
+	}
+}
 
-		hasSyn := false
+// Update Type-B contact tables from source event, reports whether frame got completed
+func (s *Simulator) handleEventB(inputEvent InputEvent) bool {
+	hasSyn := false
+
+	if s.synDropped {
+		return s.handleDropped(inputEvent, s.handleEventB)
+	}
 
-		switch inputEvent.Type {
-		case evSyn:
-			if inputEvent.Code == synReport {
-				hasSyn = true
-				fmt.Printf("SYN_REPORT\n")
+	switch inputEvent.Type {
+	case evSyn:
+		if inputEvent.Code == synReport {
+			hasSyn = true
+			fmt.Printf("SYN_REPORT\n")
+		} else if inputEvent.Code == synDropped {
+			s.synDropped = true
+			fmt.Printf("SYN_DROPPED\n")
+		}
+		break
+	case evKey:
+		if inputEvent.Code == btnTouch {
+			touchType := "UP"
+			if inputEvent.Value == 1 {
+				touchType = "DOWN"
 			}
+			fmt.Printf("BTN_TOUCH: %s\n", touchType)
+		}
+		break
+	case evAbs:
+		switch inputEvent.Code {
+		case absMtSlot:
+			s.currSlot = inputEvent.Value
+			fmt.Printf("ABS_MT_SLOT: %d\n", inputEvent.Value)
 			break
-		case evKey:
-			if inputEvent.Code == btnTouch {
-				touchType := "UP"
-				if inputEvent.Value == 1 {
-					touchType = "DOWN"
-				}
-				fmt.Printf("BTN_TOUCH: %s\n", touchType)
+		case absMtTouchMajor:
+			// The length of the major axis of the contact. The length should be given in surface units.
+			// If the surface has an X times Y resolution, the largest possible value of ABS_MT_TOUCH_MAJOR is sqrt(X^2 + Y^2), the diagonal
+			if s.isRealContact(s.currSlot) {
+				s.touchContactsB[s.currSlot].TUpdate = true
+				s.touchContactsB[s.currSlot].TMAUpdate = true
+				s.touchContactsB[s.currSlot].TouchMajor = inputEvent.Value
+			}
+			fmt.Printf("ABS_MT_TOUCH_MAJOR: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		case absMtTouchMinor:
+			// The length, in surface units, of the minor axis of the contact. If the contact is circular, this event can be omitted
+			if s.isRealContact(s.currSlot) {
+				s.touchContactsB[s.currSlot].TUpdate = true
+				s.touchContactsB[s.currSlot].TMIUpdate = true
+				s.touchContactsB[s.currSlot].TouchMinor = inputEvent.Value
+			}
+			fmt.Printf("ABS_MT_TOUCH_MINOR: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		case absMtWidthMajor:
+			// The length, in surface units, of the major axis of the approaching tool. This should be understood as the size of the tool itself.
+			// The orientation of the contact and the approaching tool are assumed to be the same
+			if s.isRealContact(s.currSlot) {
+				s.touchContactsB[s.currSlot].TUpdate = true
+				s.touchContactsB[s.currSlot].WMAUpdate = true
+				s.touchContactsB[s.currSlot].WidthMajor = inputEvent.Value
+			}
+			fmt.Printf("ABS_MT_WIDTH_MAJOR: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		case absMtWidthMinor:
+			// The length, in surface units, of the minor axis of the approaching tool. Omit if circular [4].
+			// The above four values can be used to derive additional information about the contact.
+			// The ratio ABS_MT_TOUCH_MAJOR / ABS_MT_WIDTH_MAJOR approximates the notion of pressure.
+			// The fingers of the hand and the palm all have different characteristic widths.
+			if s.isRealContact(s.currSlot) {
+				s.touchContactsB[s.currSlot].TUpdate = true
+				s.touchContactsB[s.currSlot].WMIUpdate = true
+				s.touchContactsB[s.currSlot].WidthMinor = inputEvent.Value
+			}
+			fmt.Printf("ABS_MT_WIDTH_MINOR: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		case absMtOrientation:
+			// The orientation of the touching ellipse. The value should describe a signed quarter of a revolution clockwise around the touch center.
+			// The signed value range is arbitrary, but zero should be returned for an ellipse aligned with the Y axis (north) of the surface,
+			// a negative value when the ellipse is turned to the left, and a positive value when the ellipse is turned to the right.
+			// When aligned with the X axis in the positive direction, the range max should be returned; when aligned with the X axis in the negative direction,
+			// the range -max should be returned.
+			// Touch ellipsis are symmetrical by default. For devices capable of true 360 degree orientation, the reported orientation must exceed the range max
+			// to indicate more than a quarter of a revolution. For an upside-down finger, range max * 2 should be returned.
+			// Orientation can be omitted if the touch area is circular, or if the information is not available in the kernel driver.
+			// Partial orientation support is possible if the device can distinguish between the two axis, but not (uniquely) any values in between.
+			// In such cases, the range of ABS_MT_ORIENTATION should be [0, 1] [4].
+			if s.isRealContact(s.currSlot) {
+				s.touchContactsB[s.currSlot].TUpdate = true
+				s.touchContactsB[s.currSlot].OriUpdate = true
+				s.touchContactsB[s.currSlot].Orientation = inputEvent.Value
+			}
+			fmt.Printf("ABS_MT_ORIENTATION: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		case absMtPositionX:
+			// The surface X coordinate of the center of the touching ellipse.
+			if s.isRealContact(s.currSlot) {
+				s.touchContactsB[s.currSlot].TUpdate = true
+				s.touchContactsB[s.currSlot].PosXUpdate = true
+				s.touchContactsB[s.currSlot].PositionX = inputEvent.Value
+			}
+			fmt.Printf("ABS_MT_POSITION_X: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		case absMtPositionY:
+			// The surface Y coordinate of the center of the touching ellipse.
+			if s.isRealContact(s.currSlot) {
+				s.touchContactsB[s.currSlot].TUpdate = true
+				s.touchContactsB[s.currSlot].PosYUpdate = true
+				s.touchContactsB[s.currSlot].PositionY = inputEvent.Value
+			}
+			fmt.Printf("ABS_MT_POSITION_Y: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		case absMtToolType:
+			// The type of approaching tool. A lot of kernel drivers cannot distinguish between different tool types, such as a finger or a pen.
+			// In such cases, the event should be omitted.
+			// The protocol currently supports MT_TOOL_FINGER, MT_TOOL_PEN, and MT_TOOL_PALM [2]. For type B devices, this event is handled by input core;
+			// drivers should instead use input_mt_report_slot_state(). A contact’s ABS_MT_TOOL_TYPE may change over time while still touching the device,
+			// because the firmware may not be able to determine which tool is being used when it first appears.
+			fmt.Printf("ABS_MT_TOOL_TYPE: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		case absMtBlobId:
+			// The BLOB_ID groups several packets together into one arbitrarily shaped contact. The sequence of points forms a polygon which defines the shape of the contact.
+			// This is a low-level anonymous grouping for type A devices, and should not be confused with the high-level trackingID [5].
+			// Most type A devices do not have blob capability, so drivers can safely omit this event.
+			fmt.Printf("ABS_MT_BLOB_ID: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		case absMtTrackingId:
+			// The TRACKING_ID identifies an initiated contact throughout its life cycle [5].
+			// The value range of the TRACKING_ID should be large enough to ensure unique identification of a contact maintained over an extended period of time.
+			// For type B devices, this event is handled by input core; drivers should instead use input_mt_report_slot_state().
+			s.sourceIds[s.currSlot] = inputEvent.Value
+			if inputEvent.Value != -1 {
+				s.claimSlot(s.currSlot)
+				s.touchContactsB[s.currSlot].TrackingId = s.nextTrackingId()
+				s.touchContactsB[s.currSlot].Active = true
+				s.touchContactsB[s.currSlot].TUpdate = true
+				s.touchContactsB[s.currSlot].TrackUpdate = true
+			} else if s.slotOwner(s.currSlot) == nil {
+				s.touchContactsB[s.currSlot].TrackingId = -1
+				s.touchContactsB[s.currSlot].Active = false
+				s.touchContactsB[s.currSlot].TUpdate = true
+				s.touchContactsB[s.currSlot].TrackUpdate = true
+			}
+			fmt.Printf("ABS_MT_TRACKING_ID: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		case absMtPressure:
+			// The pressure, in arbitrary units, on the contact area. May be used instead of TOUCH and WIDTH for pressure-based devices
+			// or any device with a spatial signal intensity distribution.
+			if s.isRealContact(s.currSlot) {
+				s.touchContactsB[s.currSlot].TUpdate = true
+				s.touchContactsB[s.currSlot].PressUpdate = true
+				s.touchContactsB[s.currSlot].Pressure = inputEvent.Value
 			}
+			fmt.Printf("ABS_MT_PRESSURE: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
 			break
-		case evAbs:
-			switch inputEvent.Code {
-			case absMtSlot:
-				currSlot = inputEvent.Value
-				fmt.Printf("ABS_MT_SLOT: %d\n", inputEvent.Value)
-				break
-			case absMtTouchMajor:
-				// The length of the major axis of the contact. The length should be given in surface units.
-				// If the surface has an X times Y resolution, the largest possible value of ABS_MT_TOUCH_MAJOR is sqrt(X^2 + Y^2), the diagonal
-				if touchContactsB[currSlot].Active {
-					touchContactsB[currSlot].TUpdate = true
-					touchContactsB[currSlot].TMAUpdate = true
-					touchContactsB[currSlot].TouchMajor = inputEvent.Value
+		case absMtDistance:
+			// The distance, in surface units, between the contact and the surface. Zero distance means the contact is touching the surface.
+			// A positive number means the contact is hovering above the surface.
+			fmt.Printf("ABS_MT_DISTANCE: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		case absMtToolX:
+			// The surface X coordinate of the center of the approaching tool. Omit if the device cannot distinguish between the intended touch point and the tool itself.
+			fmt.Printf("ABS_MT_TOOL_X: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		case absMtToolY:
+			// The surface Y coordinate of the center of the approaching tool. Omit if the device cannot distinguish between the intended touch point and the tool itself.
+			// The four position values can be used to separate the position of the touch from the position of the tool.
+			// If both positions are present, the major tool axis points towards the touch point [1]. Otherwise, the tool axes are aligned with the touch axes.
+			fmt.Printf("ABS_MT_TOOL_Y: %d | Slot: %d\n", inputEvent.Value, s.currSlot)
+			break
+		}
+		break
+	}
+
+	return hasSyn
+}
+
+// Write one frame of contact tables as TypeB events
+func (s *Simulator) dispatchB() {
+	if s.sink == nil {
+		return
+	}
+
+	activeSlots := 0
+
+	for idx, contact := range s.touchContactsB {
+		if contact.Active {
+			activeSlots++
+
+			writeEvent(s.sink, evAbs, absMtSlot, int32(idx))
+
+			if contact.TUpdate {
+				if contact.TrackUpdate {
+					writeEvent(s.sink, evAbs, absMtTrackingId, contact.TrackingId)
+					s.touchContactsB[idx].TrackUpdate = false
 				}
-				fmt.Printf("ABS_MT_TOUCH_MAJOR: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtTouchMinor:
-				// The length, in surface units, of the minor axis of the contact. If the contact is circular, this event can be omitted
-				if touchContactsB[currSlot].Active {
-					touchContactsB[currSlot].TUpdate = true
-					touchContactsB[currSlot].TMIUpdate = true
-					touchContactsB[currSlot].TouchMinor = inputEvent.Value
+
+				if contact.PosXUpdate {
+					writeEvent(s.sink, evAbs, absMtPositionX, contact.PositionX)
+					s.touchContactsB[idx].PosXUpdate = false
 				}
-				fmt.Printf("ABS_MT_TOUCH_MINOR: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtWidthMajor:
-				// The length, in surface units, of the major axis of the approaching tool. This should be understood as the size of the tool itself.
-				// The orientation of the contact and the approaching tool are assumed to be the same
-				if touchContactsB[currSlot].Active {
-					touchContactsB[currSlot].TUpdate = true
-					touchContactsB[currSlot].WMAUpdate = true
-					touchContactsB[currSlot].WidthMajor = inputEvent.Value
+
+				if contact.PosYUpdate {
+					writeEvent(s.sink, evAbs, absMtPositionY, contact.PositionY)
+					s.touchContactsB[idx].PosYUpdate = false
 				}
-				fmt.Printf("ABS_MT_WIDTH_MAJOR: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtWidthMinor:
-				// The length, in surface units, of the minor axis of the approaching tool. Omit if circular [4].
-				// The above four values can be used to derive additional information about the contact.
-				// The ratio ABS_MT_TOUCH_MAJOR / ABS_MT_WIDTH_MAJOR approximates the notion of pressure.
-				// The fingers of the hand and the palm all have different characteristic widths.
-				if touchContactsB[currSlot].Active {
-					touchContactsB[currSlot].TUpdate = true
-					touchContactsB[currSlot].WMIUpdate = true
-					touchContactsB[currSlot].WidthMinor = inputEvent.Value
+
+				if contact.TMAUpdate {
+					writeEvent(s.sink, evAbs, absMtTouchMajor, contact.TouchMajor)
+					s.touchContactsB[idx].TMAUpdate = false
 				}
-				fmt.Printf("ABS_MT_WIDTH_MINOR: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtOrientation:
-				// The orientation of the touching ellipse. The value should describe a signed quarter of a revolution clockwise around the touch center.
-				// The signed value range is arbitrary, but zero should be returned for an ellipse aligned with the Y axis (north) of the surface,
-				// a negative value when the ellipse is turned to the left, and a positive value when the ellipse is turned to the right.
-				// When aligned with the X axis in the positive direction, the range max should be returned; when aligned with the X axis in the negative direction,
-				// the range -max should be returned.
-				// Touch ellipsis are symmetrical by default. For devices capable of true 360 degree orientation, the reported orientation must exceed the range max
-				// to indicate more than a quarter of a revolution. For an upside-down finger, range max * 2 should be returned.
-				// Orientation can be omitted if the touch area is circular, or if the information is not available in the kernel driver.
-				// Partial orientation support is possible if the device can distinguish between the two axis, but not (uniquely) any values in between.
-				// In such cases, the range of ABS_MT_ORIENTATION should be [0, 1] [4].
-				if touchContactsB[currSlot].Active {
-					touchContactsB[currSlot].TUpdate = true
-					touchContactsB[currSlot].OriUpdate = true
-					touchContactsB[currSlot].Orientation = inputEvent.Value
+
+				if contact.TMIUpdate {
+					writeEvent(s.sink, evAbs, absMtTouchMinor, contact.TouchMinor)
+					s.touchContactsB[idx].TMIUpdate = false
 				}
-				fmt.Printf("ABS_MT_ORIENTATION: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtPositionX:
-				// The surface X coordinate of the center of the touching ellipse.
-				if touchContactsB[currSlot].Active {
-					touchContactsB[currSlot].TUpdate = true
-					touchContactsB[currSlot].PosXUpdate = true
-					touchContactsB[currSlot].PositionX = inputEvent.Value
+
+				if contact.WMAUpdate {
+					writeEvent(s.sink, evAbs, absMtWidthMajor, contact.WidthMajor)
+					s.touchContactsB[idx].WMAUpdate = false
 				}
-				fmt.Printf("ABS_MT_POSITION_X: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtPositionY:
-				// The surface Y coordinate of the center of the touching ellipse.
-				if touchContactsB[currSlot].Active {
-					touchContactsB[currSlot].TUpdate = true
-					touchContactsB[currSlot].PosYUpdate = true
-					touchContactsB[currSlot].PositionY = inputEvent.Value
+
+				if contact.WMIUpdate {
+					writeEvent(s.sink, evAbs, absMtWidthMinor, contact.WidthMinor)
+					s.touchContactsB[idx].WMIUpdate = false
 				}
-				fmt.Printf("ABS_MT_POSITION_Y: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtToolType:
-				// The type of approaching tool. A lot of kernel drivers cannot distinguish between different tool types, such as a finger or a pen.
-				// In such cases, the event should be omitted.
-				// The protocol currently supports MT_TOOL_FINGER, MT_TOOL_PEN, and MT_TOOL_PALM [2]. For type B devices, this event is handled by input core;
-				// drivers should instead use input_mt_report_slot_state(). A contact’s ABS_MT_TOOL_TYPE may change over time while still touching the device,
-				// because the firmware may not be able to determine which tool is being used when it first appears.
-				fmt.Printf("ABS_MT_TOOL_TYPE: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtBlobId:
-				// The BLOB_ID groups several packets together into one arbitrarily shaped contact. The sequence of points forms a polygon which defines the shape of the contact.
-				// This is a low-level anonymous grouping for type A devices, and should not be confused with the high-level trackingID [5].
-				// Most type A devices do not have blob capability, so drivers can safely omit this event.
-				fmt.Printf("ABS_MT_BLOB_ID: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtTrackingId:
-				// The TRACKING_ID identifies an initiated contact throughout its life cycle [5].
-				// The value range of the TRACKING_ID should be large enough to ensure unique identification of a contact maintained over an extended period of time.
-				// For type B devices, this event is handled by input core; drivers should instead use input_mt_report_slot_state().
-				touchContactsB[currSlot].TUpdate = true
-				touchContactsB[currSlot].TrackUpdate = true
-				touchContactsB[currSlot].TrackingId = inputEvent.Value
-				touchContactsB[currSlot].Active = inputEvent.Value != -1
-				fmt.Printf("ABS_MT_TRACKING_ID: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtPressure:
-				// The pressure, in arbitrary units, on the contact area. May be used instead of TOUCH and WIDTH for pressure-based devices
-				// or any device with a spatial signal intensity distribution.
-				if touchContactsB[currSlot].Active {
-					touchContactsB[currSlot].TUpdate = true
-					touchContactsB[currSlot].PressUpdate = true
-					touchContactsB[currSlot].Pressure = inputEvent.Value
+
+				if contact.PressUpdate {
+					writeEvent(s.sink, evAbs, absMtPressure, contact.Pressure)
+					s.touchContactsB[idx].PressUpdate = false
 				}
-				fmt.Printf("ABS_MT_PRESSURE: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtDistance:
-				// The distance, in surface units, between the contact and the surface. Zero distance means the contact is touching the surface.
-				// A positive number means the contact is hovering above the surface.
-				fmt.Printf("ABS_MT_DISTANCE: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtToolX:
-				// The surface X coordinate of the center of the approaching tool. Omit if the device cannot distinguish between the intended touch point and the tool itself.
-				fmt.Printf("ABS_MT_TOOL_X: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
-			case absMtToolY:
-				// The surface Y coordinate of the center of the approaching tool. Omit if the device cannot distinguish between the intended touch point and the tool itself.
-				// The four position values can be used to separate the position of the touch from the position of the tool.
-				// If both positions are present, the major tool axis points towards the touch point [1]. Otherwise, the tool axes are aligned with the touch axes.
-				fmt.Printf("ABS_MT_TOOL_Y: %d | Slot: %d\n", inputEvent.Value, currSlot)
-				break
+
+				if contact.OriUpdate {
+					writeEvent(s.sink, evAbs, absMtOrientation, contact.Orientation)
+					s.touchContactsB[idx].OriUpdate = false
+				}
+
+				s.touchContactsB[idx].TUpdate = false
 			}
-			break
+		} else if !contact.Active && contact.TrackUpdate {
+			writeEvent(s.sink, evAbs, absMtSlot, int32(idx))
+			writeEvent(s.sink, evAbs, absMtTrackingId, -1)
+			if s.touchDevice.hasPressure {
+				writeEvent(s.sink, evAbs, absMtPressure, 0)
+			}
+			if s.touchDevice.hasOrientation {
+				writeEvent(s.sink, evAbs, absMtOrientation, 0)
+			}
+			s.touchContactsB[idx].TrackUpdate = false
+			s.touchContactsB[idx].TUpdate = false
 		}
+	}
 
-		if hasSyn {
-			syncChannel <- true
-			fmt.Printf("-------------------------------------\n")
-		}
+	if activeSlots == 0 && s.isBtnDown { //Button Up
+		s.isBtnDown = false
+		writeEvent(s.sink, evKey, btnTouch, 0)
+	} else if activeSlots > 0 && !s.isBtnDown { //Button Down
+		s.isBtnDown = true // Button down state change here
+		writeEvent(s.sink, evKey, btnTouch, 1)
 	}
+
+	writeEvent(s.sink, evSyn, synReport, 0)
 }
 
-// Writing Touch Inputs to TypeB device
-func eventDispatcherB() {
-	var isBtnDown bool = false
+// Setup Start simulation on the first touch device found
+func (s *Simulator) Setup(mode TypeMode, width, height int32) error {
+	return s.SetupFilter(mode, width, height, DeviceFilter{})
+}
 
-	for {
-		select {
-		case <-stopChannel:
-			return
-		default:
+// SetupFilter Start simulation on the first touch device matching filter
+func (s *Simulator) SetupFilter(mode TypeMode, width, height int32, filter DeviceFilter) error {
+	tDevs, err := s.factory.InputDevices()
+	if err != nil {
+		return err
+	}
+
+	selected := SelectDevices(tDevs, filter)
+	if len(selected) < 1 {
+		CloseDevices(tDevs, nil)
+		return ErrNoDevice
+	}
+
+	CloseDevices(tDevs, selected[0])
+	return s.Start(mode, width, height, selected[0])
+}
+
+// Start Clone given touch device and start bridging its events.
+// Failures match ErrUinputUnavailable or ErrGrabFailed with errors.Is, and os.ErrPermission when access got denied.
+func (s *Simulator) Start(mode TypeMode, width, height int32, inDev *InputDevice) error {
+	//Wait for a running Stop to finish its teardown
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.touchStart {
+		source, sink, err := s.openBridge(mode, inDev)
+		if err != nil {
+			return err
 		}
 
-		select {
-		case <-syncChannel:
-			{
-				activeSlots := 0
-
-				for idx, contact := range touchContactsB {
-					if contact.Active {
-						activeSlots++
-
-						writeEvent(uInputTouch.File, evAbs, absMtSlot, int32(idx))
-
-						if contact.TUpdate {
-							if contact.TrackUpdate {
-								writeEvent(uInputTouch.File, evAbs, absMtTrackingId, contact.TrackingId)
-								touchContactsB[idx].TrackUpdate = false
-							}
-
-							if contact.PosXUpdate {
-								writeEvent(uInputTouch.File, evAbs, absMtPositionX, contact.PositionX)
-								touchContactsB[idx].PosXUpdate = false
-							}
-
-							if contact.PosYUpdate {
-								writeEvent(uInputTouch.File, evAbs, absMtPositionY, contact.PositionY)
-								touchContactsB[idx].PosYUpdate = false
-							}
-
-							if contact.TMAUpdate {
-								writeEvent(uInputTouch.File, evAbs, absMtTouchMajor, contact.TouchMajor)
-								touchContactsB[idx].TMAUpdate = false
-							}
-
-							if contact.TMIUpdate {
-								writeEvent(uInputTouch.File, evAbs, absMtTouchMinor, contact.TouchMinor)
-								touchContactsB[idx].TMIUpdate = false
-							}
-
-							if contact.WMAUpdate {
-								writeEvent(uInputTouch.File, evAbs, absMtWidthMajor, contact.WidthMajor)
-								touchContactsB[idx].WMAUpdate = false
-							}
-
-							if contact.WMIUpdate {
-								writeEvent(uInputTouch.File, evAbs, absMtWidthMinor, contact.WidthMinor)
-								touchContactsB[idx].WMIUpdate = false
-							}
-
-							if contact.PressUpdate {
-								writeEvent(uInputTouch.File, evAbs, absMtPressure, contact.Pressure)
-								touchContactsB[idx].PressUpdate = false
-							}
-
-							if contact.OriUpdate {
-								writeEvent(uInputTouch.File, evAbs, absMtOrientation, contact.Orientation)
-								touchContactsB[idx].OriUpdate = false
-							}
-
-							touchContactsB[idx].TUpdate = false
-						}
-					} else if !contact.Active && contact.TrackUpdate {
-						writeEvent(uInputTouch.File, evAbs, absMtSlot, int32(idx))
-						writeEvent(uInputTouch.File, evAbs, absMtTrackingId, -1)
-						if touchDevice.hasPressure {
-							writeEvent(uInputTouch.File, evAbs, absMtPressure, 0)
-						}
-						if touchDevice.hasOrientation {
-							writeEvent(uInputTouch.File, evAbs, absMtOrientation, 0)
-						}
-						touchContactsB[idx].TrackUpdate = false
-						touchContactsB[idx].TUpdate = false
-					}
-				}
+		s.sink = sink
+		s.source = source
 
-				if activeSlots == 0 && isBtnDown { //Button Up
-					isBtnDown = false
-					writeEvent(uInputTouch.File, evKey, btnTouch, 0)
-				} else if activeSlots > 0 && !isBtnDown { //Button Down
-					isBtnDown = true // Button down state change here
-					writeEvent(uInputTouch.File, evKey, btnTouch, 1)
-				}
+		s.initState(mode, width, height, inDev)
 
-				writeEvent(uInputTouch.File, evSyn, synReport, 0)
-			}
-		default:
+		//Start Threads
+		s.wg.Add(1)
+		if mode == TYPEA || mode == TYPEARND {
+			go s.eventDispatcherA()
+		} else {
+			go s.eventDispatcherB()
 		}
+		s.startReader()
+
+		s.touchStart = true
 	}
+	return nil
 }
 
-func touchInputSetup(mode TypeMode, width, height int32) bool {
-	tDevs, err := getInputDevices()
+// Clone device and grab it, without touching simulation state
+func (s *Simulator) openBridge(mode TypeMode, inDev *InputDevice) (EventSource, EventSink, error) {
+	//Setup UInput Touch Device
+	sink, err := s.factory.CreateSink(mode, inDev)
 	if err != nil {
-		return false
+		return nil, nil, err
 	}
 
-	if len(tDevs) < 1 {
-		return false
+	//Stop Primary Touch Device
+	source, err := s.factory.OpenSource(inDev)
+	if err != nil {
+		_ = sink.Close()
+		return nil, nil, err
+	}
+
+	//Read Type-A and Single-Touch Devices as Type-B
+	if inDev.Protocol != PROTOCOLB {
+		source = newSlottedSource(source, inDev)
+	}
+
+	return source, sink, nil
+}
+
+// Start reader thread for current source, lock must be held
+func (s *Simulator) startReader() {
+	s.wg.Add(1)
+	if s.currMode == TYPEA || s.currMode == TYPEARND {
+		go s.eventReaderA()
+	} else {
+		go s.eventReaderB()
 	}
-	return touchInputStart(mode, width, height, tDevs[0])
 }
 
-func touchInputStart(mode TypeMode, width, height int32, inDev *InputDevice) bool {
-	if !touchStart {
-		currMode = mode
+// Reset channels, contact tables and fake contact defaults for a new run
+func (s *Simulator) initState(mode TypeMode, width, height int32, inDev *InputDevice) {
+	s.currMode = mode
 
-		//Init Things
-		touchDevice = inDev
-		displayWidth = width
-		displayHeight = height
+	//Init Things
+	s.touchDevice = inDev
+	s.transform.Width = width
+	s.transform.Height = height
 
-		syncChannel = make(chan bool)
-		stopChannel = make(chan bool)
+	s.syncChannel = make(chan bool)
+	s.stopChannel = make(chan bool)
 
-		if mode == TYPEA || mode == TYPEARND {
-			//Setup TypeA UInput Touch Device
-			if mode == TYPEARND {
-				tsDev, err := newTypeADevRandom(inDev)
-				if err != nil {
-					return false
-				}
-				uInputTouch = tsDev
-			} else {
-				tsDev, err := newTypeADevSame(inDev)
-				if err != nil {
-					return false
-				}
-				uInputTouch = tsDev
-			}
+	s.readErr = nil
+	s.lost = false
 
-			//Set Default Values in Touch Contacts Array
-			touchContactsA = make([]TouchContactA, touchDevice.Slots)
-			for idx := range touchContactsA {
-				touchContactsA[idx].PosX = -1
-				touchContactsA[idx].PosY = -1
-				touchContactsA[idx].Active = false
-			}
+	s.resetContacts()
+}
 
-			//Start Threads
-			go eventReaderA()
-			go eventDispatcherA()
-		} else {
-			//Setup TypeB UInput Touch Device
-			tsDev, err := newTypeBDevSame(inDev)
-			if err != nil {
-				return false
-			}
-			uInputTouch = tsDev
+// Reset contact tables, fake pointers and fake contact shape for current touch device
+func (s *Simulator) resetContacts() {
+	mode := s.currMode
 
-			if touchDevice.hasTouchMajor {
-				fakeTouchMajor = int32(float32(touchDevice.AbsInfos[absMtTouchMajor].Maximum) * 0.14)
-			}
-			if touchDevice.hasTouchMinor {
-				fakeTouchMinor = int32(float32(touchDevice.AbsInfos[absMtTouchMinor].Maximum) * 0.10)
-			}
-			if touchDevice.hasWidthMajor {
-				fakeWidthMajor = int32(float32(touchDevice.AbsInfos[absMtWidthMajor].Maximum) * 0.14)
-			}
-			if touchDevice.hasWidthMinor {
-				fakeWidthMinor = int32(float32(touchDevice.AbsInfos[absMtWidthMinor].Maximum) * 0.10)
-			}
-			if touchDevice.hasOrientation {
-				fakeOrientation = int32(float32(touchDevice.AbsInfos[absMtOrientation].Maximum) * 0.28)
-			}
-			if touchDevice.hasPressure {
-				fakePressure = int32(float32(touchDevice.AbsInfos[absMtPressure].Maximum) * 0.35)
-			}
+	s.fakePointers = make(map[int]*FakePointer)
+	s.lastTrackingId = -1
+	s.currSlot = 0
+	s.synDropped = false
+	s.isBtnDown = false
 
-			//Set Default Values in Touch Contacts Array
-			touchContactsB = make([]TouchContactB, touchDevice.Slots)
-			for idx := range touchContactsB {
-				touchContactsB[idx].TouchMajor = -1
-				touchContactsB[idx].TouchMinor = -1
-				touchContactsB[idx].WidthMajor = -1
-				touchContactsB[idx].WidthMinor = -1
-				touchContactsB[idx].Orientation = -1
-				touchContactsB[idx].PositionX = -1
-				touchContactsB[idx].PositionY = -1
-				touchContactsB[idx].TrackingId = -1
-				touchContactsB[idx].Pressure = -1
-
-				touchContactsB[idx].Active = false
-				touchContactsB[idx].TUpdate = false
-				touchContactsB[idx].TMAUpdate = false
-				touchContactsB[idx].TMIUpdate = false
-				touchContactsB[idx].WMAUpdate = false
-				touchContactsB[idx].WMIUpdate = false
-				touchContactsB[idx].OriUpdate = false
-				touchContactsB[idx].PosXUpdate = false
-				touchContactsB[idx].PosYUpdate = false
-				touchContactsB[idx].TrackUpdate = false
-				touchContactsB[idx].PressUpdate = false
-			}
+	s.sourceIds = make([]int32, s.touchDevice.Slots)
+	for idx := range s.sourceIds {
+		s.sourceIds[idx] = -1
+	}
 
-			//Start Threads
-			go eventReaderB()
-			go eventDispatcherB()
+	if !s.fakeShapeSet {
+		s.fakeShape = DeviceShape(s.touchDevice)
+	}
+
+	if mode == TYPEA || mode == TYPEARND {
+		//Set Default Values in Touch Contacts Array
+		s.touchContactsA = make([]TouchContactA, s.touchDevice.Slots)
+		for idx := range s.touchContactsA {
+			s.touchContactsA[idx].PosX = -1
+			s.touchContactsA[idx].PosY = -1
+			s.touchContactsA[idx].Active = false
+		}
+	} else {
+		//Set Default Values in Touch Contacts Array
+		s.touchContactsB = make([]TouchContactB, s.touchDevice.Slots)
+		for idx := range s.touchContactsB {
+			s.resetContactB(int32(idx))
 		}
+	}
+}
+
+// Stop Stop bridging and destroy the UInput clone
+func (s *Simulator) Stop() {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	s.mu.Lock()
+	if !s.touchStart || s.touchDevice == nil {
+		s.mu.Unlock()
+		return
+	}
+
+	close(s.stopChannel)
+	s.touchStart = false
+	source := s.source
+	s.mu.Unlock()
+
+	//Source is gone while waiting for a lost device to return
+	if source != nil {
+		_ = source.Close()
+	}
+	s.wg.Wait()
+
+	s.mu.Lock()
+	sink := s.sink
+	s.source = nil
+	s.sink = nil
+	s.touchDevice = nil
+	s.mu.Unlock()
 
-		touchStart = true
+	if sink != nil {
+		_ = sink.Close()
 	}
-	return true
+
+	_ = s.StopRecording()
 }
 
-func touchInputStop() {
-	if touchStart && touchDevice != nil {
-		stopChannel <- true
+// VirtualDevice Fetch UInput clone of running simulation, nil when stopped or the sink has no device
+func (s *Simulator) VirtualDevice() *InputDevice {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		if uInputTouch != nil {
-			_ = releaseDevice(uInputTouch.File)
-			_ = uInputTouch.File.Close()
-		}
-		_ = touchDevice.Release()
+	if sink, ok := s.sink.(DeviceSink); ok && s.touchStart {
+		return sink.Device()
+	}
+	return nil
+}
+
+// Keep error which ended the reader, unless it just reports Stop closing the source.
+// Losing the device starts waiting for it to return.
+func (s *Simulator) readerFailed(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		uInputTouch = nil
-		touchDevice = nil
+	select {
+	case <-s.stopChannel:
+		return
+	default:
+	}
 
-		touchStart = false
+	s.readErr = fmt.Errorf("read %s: %w", s.touchDevice.Path, err)
+	if s.autoRebridge && isDeviceLost(err) {
+		s.wg.Add(1)
+		go s.rebridge()
+	}
+}
+
+// Err Fetch error which stopped reading the touch device, like ENODEV once it got unplugged.
+// It gets cleared once a lost device got bridged again. A failed resync after dropped events
+// shows up as ErrResyncFailed until a later resync works.
+func (s *Simulator) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.readErr
+}
+
+// Notify dispatcher about pending contact changes
+func (s *Simulator) notify() {
+	select {
+	case s.syncChannel <- true:
+	case <-s.stopChannel:
 	}
 }
 
 ///----------Fake Touch Input-----------///
 
-func sendTouchMove(x, y int32) {
-	if !touchStart {
+// FakePointer A virtual finger injected into the merged touch stream
+type FakePointer struct {
+	Slot       int32
+	TrackingId int32
+	PosX       int32
+	PosY       int32
+	Shape      ContactShape
+}
+
+// Reset Type-B contact slot to its idle state
+func (s *Simulator) resetContactB(slot int32) {
+	s.touchContactsB[slot].TouchMajor = -1
+	s.touchContactsB[slot].TouchMinor = -1
+	s.touchContactsB[slot].WidthMajor = -1
+	s.touchContactsB[slot].WidthMinor = -1
+	s.touchContactsB[slot].Orientation = -1
+	s.touchContactsB[slot].PositionX = -1
+	s.touchContactsB[slot].PositionY = -1
+	s.touchContactsB[slot].TrackingId = -1
+	s.touchContactsB[slot].Pressure = -1
+
+	s.touchContactsB[slot].Active = false
+	s.touchContactsB[slot].TUpdate = false
+	s.touchContactsB[slot].TMAUpdate = false
+	s.touchContactsB[slot].TMIUpdate = false
+	s.touchContactsB[slot].WMAUpdate = false
+	s.touchContactsB[slot].WMIUpdate = false
+	s.touchContactsB[slot].OriUpdate = false
+	s.touchContactsB[slot].PosXUpdate = false
+	s.touchContactsB[slot].PosYUpdate = false
+	s.touchContactsB[slot].TrackUpdate = false
+	s.touchContactsB[slot].PressUpdate = false
+}
+
+// Determine if slot holds an active contact of the real device
+func (s *Simulator) isRealContact(slot int32) bool {
+	return s.touchContactsB[slot].Active && s.slotOwner(slot) == nil
+}
+
+// Hand slot over to the real device, moving fake pointer in it to another free slot.
+// Fake pointer is dropped when no slot is left, real fingers always win.
+func (s *Simulator) claimSlot(slot int32) {
+	ptr := s.slotOwner(slot)
+	if ptr == nil {
 		return
 	}
 
-	if !touchSend {
-		touchSend = true
+	newSlot := s.freeSlot()
+	if newSlot < 0 {
+		for id, p := range s.fakePointers {
+			if p == ptr {
+				delete(s.fakePointers, id)
+			}
+		}
+	} else {
+		ptr.Slot = newSlot
+		s.writeFakeContact(ptr)
 	}
 
-	x = (x * touchDevice.TouchXMax / displayWidth) + touchDevice.TouchXMin
-	y = (y * touchDevice.TouchYMax / displayHeight) + touchDevice.TouchYMin
-
-	if currMode == TYPEA {
-		touchContactsA[fakeContact].PosX = x
-		touchContactsA[fakeContact].PosY = y
-		touchContactsA[fakeContact].Active = true
+	if s.currMode == TYPEB {
+		// New tracking id in this slot implicitly ends the moved contact
+		s.resetContactB(slot)
 	} else {
-		if touchDevice.hasTouchMajor {
-			touchContactsB[fakeContact].TouchMajor = fakeTouchMajor
-			touchContactsB[fakeContact].TMAUpdate = true
-		}
-		if touchDevice.hasTouchMinor {
-			touchContactsB[fakeContact].TouchMinor = fakeTouchMinor
-			touchContactsB[fakeContact].TMIUpdate = true
+		s.touchContactsA[slot].PosX = -1
+		s.touchContactsA[slot].PosY = -1
+		s.touchContactsA[slot].Active = false
+	}
+}
+
+// Allocate tracking id not used by any live real or fake contact,
+// so the merged stream never carries the same id twice
+func (s *Simulator) nextTrackingId() int32 {
+	info := s.touchDevice.AbsInfos[absMtTrackingId]
+
+	minId := info.Minimum
+	if minId < 0 {
+		minId = 0
+	}
+
+	id := s.lastTrackingId
+	for i := int64(0); i <= int64(info.Maximum-minId); i++ {
+		id++
+		if id < minId || id > info.Maximum {
+			id = minId
 		}
-		if touchDevice.hasWidthMajor {
-			touchContactsB[fakeContact].WidthMajor = fakeWidthMajor
-			touchContactsB[fakeContact].WMAUpdate = true
+
+		if !s.isTrackingIdUsed(id) {
+			break
 		}
-		if touchDevice.hasWidthMinor {
-			touchContactsB[fakeContact].WidthMinor = fakeWidthMinor
-			touchContactsB[fakeContact].WMIUpdate = true
+	}
+
+	s.lastTrackingId = id
+	return id
+}
+
+// Determine if tracking id belongs to a live contact
+func (s *Simulator) isTrackingIdUsed(id int32) bool {
+	for _, ptr := range s.fakePointers {
+		if ptr.TrackingId == id {
+			return true
 		}
-		if touchDevice.hasOrientation {
-			touchContactsB[fakeContact].Orientation = fakeOrientation
-			touchContactsB[fakeContact].OriUpdate = true
+	}
+
+	for _, contact := range s.touchContactsB {
+		if contact.Active && contact.TrackingId == id {
+			return true
 		}
-		if touchDevice.hasPressure {
-			touchContactsB[fakeContact].Pressure = fakePressure
-			touchContactsB[fakeContact].PressUpdate = true
+	}
+	return false
+}
+
+// Find a slot not used by real or fake contacts, searching from the top
+// so injected fingers stay away from the slots real fingers fill first
+func (s *Simulator) freeSlot() int32 {
+	for slot := s.touchDevice.Slots - 1; slot >= 0; slot-- {
+		if s.isFreeSlot(slot) {
+			return slot
 		}
-		if touchContactsB[fakeContact].TrackingId < 0 {
-			touchContactsB[fakeContact].TrackingId = touchDevice.AbsInfos[absMtTrackingId].Maximum - 2
-			touchContactsB[fakeContact].TrackUpdate = true
+	}
+	return -1
+}
+
+// Determine if slot is free of real and fake contacts, including ones lifted in the pending frame
+func (s *Simulator) isFreeSlot(slot int32) bool {
+	if s.slotOwner(slot) != nil {
+		return false
+	}
+
+	if s.currMode == TYPEB {
+		contact := s.touchContactsB[slot]
+		return !contact.Active && !contact.TrackUpdate
+	}
+	return !s.touchContactsA[slot].Active
+}
+
+// Fetch fake pointer which occupies given slot
+func (s *Simulator) slotOwner(slot int32) *FakePointer {
+	for _, ptr := range s.fakePointers {
+		if ptr.Slot == slot {
+			return ptr
 		}
+	}
+	return nil
+}
 
-		touchContactsB[fakeContact].PositionX = x
-		touchContactsB[fakeContact].PositionY = y
-		touchContactsB[fakeContact].PosXUpdate = true
-		touchContactsB[fakeContact].PosYUpdate = true
+// Transform display coordinates into touch device coordinates
+func (s *Simulator) toTouchCoords(x, y int32) (int32, int32) {
+	return s.transform.Apply(s.touchDevice, x, y)
+}
 
-		touchContactsB[fakeContact].Active = true
-		touchContactsB[fakeContact].TUpdate = true
+// Write fake pointer's state into its contact slot
+func (s *Simulator) writeFakeContact(ptr *FakePointer) {
+	if s.currMode != TYPEB {
+		s.touchContactsA[ptr.Slot].PosX = ptr.PosX
+		s.touchContactsA[ptr.Slot].PosY = ptr.PosY
+		s.touchContactsA[ptr.Slot].Active = true
+		return
 	}
 
-	syncChannel <- true
+	contact := &s.touchContactsB[ptr.Slot]
 
-	time.Sleep(15 * time.Millisecond)
+	if s.touchDevice.hasTouchMajor {
+		contact.TouchMajor = ptr.Shape.TouchMajor
+		contact.TMAUpdate = true
+	}
+	if s.touchDevice.hasTouchMinor {
+		contact.TouchMinor = ptr.Shape.TouchMinor
+		contact.TMIUpdate = true
+	}
+	if s.touchDevice.hasWidthMajor {
+		contact.WidthMajor = ptr.Shape.WidthMajor
+		contact.WMAUpdate = true
+	}
+	if s.touchDevice.hasWidthMinor {
+		contact.WidthMinor = ptr.Shape.WidthMinor
+		contact.WMIUpdate = true
+	}
+	if s.touchDevice.hasOrientation {
+		contact.Orientation = ptr.Shape.Orientation
+		contact.OriUpdate = true
+	}
+	if s.touchDevice.hasPressure {
+		contact.Pressure = ptr.Shape.Pressure
+		contact.PressUpdate = true
+	}
+	if contact.TrackingId != ptr.TrackingId {
+		contact.TrackingId = ptr.TrackingId
+		contact.TrackUpdate = true
+	}
+
+	contact.PositionX = ptr.PosX
+	contact.PositionY = ptr.PosY
+	contact.PosXUpdate = true
+	contact.PosYUpdate = true
+
+	contact.Active = true
+	contact.TUpdate = true
 }
 
-func sendTouchUp() {
-	if !touchStart || !touchSend {
+// Reset contact slot released by a fake pointer
+func (s *Simulator) clearFakeContact(ptr *FakePointer) {
+	if s.currMode != TYPEB {
+		s.touchContactsA[ptr.Slot].PosX = -1
+		s.touchContactsA[ptr.Slot].PosY = -1
+		s.touchContactsA[ptr.Slot].Active = false
 		return
 	}
 
-	touchSend = false
+	contact := &s.touchContactsB[ptr.Slot]
 
-	if currMode == TYPEA {
-		touchContactsA[fakeContact].PosX = -1
-		touchContactsA[fakeContact].PosY = -1
-		touchContactsA[fakeContact].Active = false
-	} else {
-		if touchDevice.hasTouchMajor {
-			touchContactsB[fakeContact].TouchMajor = -1
-		}
-		if touchDevice.hasTouchMinor {
-			touchContactsB[fakeContact].TouchMinor = -1
-		}
-		if touchDevice.hasWidthMajor {
-			touchContactsB[fakeContact].WidthMajor = -1
-		}
-		if touchDevice.hasWidthMinor {
-			touchContactsB[fakeContact].WidthMinor = -1
-		}
-		if touchDevice.hasOrientation {
-			touchContactsB[fakeContact].Orientation = 0
-			touchContactsB[fakeContact].OriUpdate = true
-		}
-		if touchDevice.hasPressure {
-			touchContactsB[fakeContact].Pressure = 0
-			touchContactsB[fakeContact].PressUpdate = true
-		}
+	if s.touchDevice.hasTouchMajor {
+		contact.TouchMajor = -1
+	}
+	if s.touchDevice.hasTouchMinor {
+		contact.TouchMinor = -1
+	}
+	if s.touchDevice.hasWidthMajor {
+		contact.WidthMajor = -1
+	}
+	if s.touchDevice.hasWidthMinor {
+		contact.WidthMinor = -1
+	}
+	if s.touchDevice.hasOrientation {
+		contact.Orientation = 0
+		contact.OriUpdate = true
+	}
+	if s.touchDevice.hasPressure {
+		contact.Pressure = 0
+		contact.PressUpdate = true
+	}
+
+	contact.TrackingId = -1
+	contact.PositionX = -1
+	contact.PositionY = -1
+	contact.Active = false
+	contact.TUpdate = true
+	contact.TrackUpdate = true
+}
+
+// Touch down fake pointer id at touch device coordinates, lock must be held
+func (s *Simulator) downPointer(id int, x, y int32) error {
+	if _, ok := s.fakePointers[id]; ok {
+		return ErrPointerDown
+	}
+
+	slot := s.freeSlot()
+	if slot < 0 {
+		return ErrNoFreeSlot
+	}
 
-		touchContactsB[fakeContact].TrackingId = -1
-		touchContactsB[fakeContact].PositionX = -1
-		touchContactsB[fakeContact].PositionY = -1
-		touchContactsB[fakeContact].Active = false
-		touchContactsB[fakeContact].TUpdate = true
-		touchContactsB[fakeContact].TrackUpdate = true
+	ptr := &FakePointer{
+		Slot:       slot,
+		TrackingId: s.nextTrackingId(),
+		PosX:       x,
+		PosY:       y,
+		Shape:      s.fakeShape,
 	}
 
-	syncChannel <- true
+	s.fakePointers[id] = ptr
+	s.writeFakeContact(ptr)
+	return nil
+}
+
+// Move fake pointer id to touch device coordinates, lock must be held
+func (s *Simulator) movePointer(id int, x, y int32) error {
+	ptr, ok := s.fakePointers[id]
+	if !ok {
+		return ErrPointerUp
+	}
+
+	ptr.PosX = x
+	ptr.PosY = y
+	s.writeFakeContact(ptr)
+	return nil
+}
+
+// Lift fake pointer id, lock must be held
+func (s *Simulator) upPointer(id int) error {
+	ptr, ok := s.fakePointers[id]
+	if !ok {
+		return ErrPointerUp
+	}
+
+	delete(s.fakePointers, id)
+	s.clearFakeContact(ptr)
+	return nil
+}
+
+// Fetch why fake touches can't be injected right now, lock must be held
+func (s *Simulator) checkRunning() error {
+	if !s.touchStart {
+		return ErrNotStarted
+	}
+	if s.lost {
+		return ErrDeviceLost
+	}
+	return nil
+}
+
+// Down Touch down fake pointer id at given display coordinates
+func (s *Simulator) Down(id int, x, y int32) error {
+	s.mu.Lock()
+
+	if err := s.checkRunning(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	x, y = s.toTouchCoords(x, y)
+	err := s.downPointer(id, x, y)
+
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	s.notify()
+	return nil
+}
+
+// Move Move fake pointer id to given display coordinates
+func (s *Simulator) Move(id int, x, y int32) error {
+	s.mu.Lock()
+
+	if err := s.checkRunning(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	x, y = s.toTouchCoords(x, y)
+	err := s.movePointer(id, x, y)
+
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	s.notify()
+	return nil
+}
+
+// Up Lift fake pointer id
+func (s *Simulator) Up(id int) error {
+	s.mu.Lock()
+
+	if err := s.checkRunning(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	err := s.upPointer(id)
+
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	s.notify()
+	return nil
+}
+
+// TouchMove Move fake touch to given display coordinates, touching down if needed
+func (s *Simulator) TouchMove(x, y int32) {
+	if s.Move(0, x, y) == ErrPointerUp {
+		_ = s.Down(0, x, y)
+	}
+
+	time.Sleep(15 * time.Millisecond)
+}
+
+// TouchUp Lift fake touch
+func (s *Simulator) TouchUp() {
+	if s.Up(0) != nil {
+		return
+	}
 
 	time.Sleep(15 * time.Millisecond)
 }
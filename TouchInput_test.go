@@ -0,0 +1,439 @@
+package touchsimulation
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+var eventTypeNames = map[uint16]string{
+	evSyn: "EV_SYN",
+	evKey: "EV_KEY",
+	evAbs: "EV_ABS",
+}
+
+var eventCodeNames = map[uint16]map[uint16]string{
+	evSyn: {
+		synReport:   "SYN_REPORT",
+		synMtReport: "SYN_MT_REPORT",
+		synDropped:  "SYN_DROPPED",
+	},
+	evKey: {
+		btnTouch: "BTN_TOUCH",
+	},
+	evAbs: {
+		absX:             "ABS_X",
+		absY:             "ABS_Y",
+		absPressure:      "ABS_PRESSURE",
+		absMtSlot:        "ABS_MT_SLOT",
+		absMtTouchMajor:  "ABS_MT_TOUCH_MAJOR",
+		absMtTouchMinor:  "ABS_MT_TOUCH_MINOR",
+		absMtWidthMajor:  "ABS_MT_WIDTH_MAJOR",
+		absMtWidthMinor:  "ABS_MT_WIDTH_MINOR",
+		absMtOrientation: "ABS_MT_ORIENTATION",
+		absMtPositionX:   "ABS_MT_POSITION_X",
+		absMtPositionY:   "ABS_MT_POSITION_Y",
+		absMtToolType:    "ABS_MT_TOOL_TYPE",
+		absMtBlobId:      "ABS_MT_BLOB_ID",
+		absMtTrackingId:  "ABS_MT_TRACKING_ID",
+		absMtPressure:    "ABS_MT_PRESSURE",
+		absMtDistance:    "ABS_MT_DISTANCE",
+		absMtToolX:       "ABS_MT_TOOL_X",
+		absMtToolY:       "ABS_MT_TOOL_Y",
+	},
+}
+
+// 1080x2340 panel mapped 1:1 on a 1080x2340 display
+func newTestDevice() *InputDevice {
+	return NewFakeTouchDevice("test_ts", map[int]AbsInfo{
+		absMtSlot:        {Maximum: 9},
+		absMtTouchMajor:  {Maximum: 255},
+		absMtWidthMajor:  {Maximum: 255},
+		absMtOrientation: {Minimum: -90, Maximum: 90},
+		absMtPositionX:   {Maximum: 1079},
+		absMtPositionY:   {Maximum: 2339},
+		absMtTrackingId:  {Maximum: 65535},
+		absMtPressure:    {Maximum: 255},
+	})
+}
+
+// 1080x2340 Type-A panel without slots and tracking ids
+func newTypeATestDevice() *InputDevice {
+	return NewFakeTouchDevice("test_ts_a", map[int]AbsInfo{
+		absMtTouchMajor: {Maximum: 255},
+		absMtPositionX:  {Maximum: 1079},
+		absMtPositionY:  {Maximum: 2339},
+		absMtPressure:   {Maximum: 255},
+	})
+}
+
+// 1080x2340 single-touch panel reporting ABS_X/ABS_Y
+func newSingleTestDevice() *InputDevice {
+	return NewFakeTouchDevice("test_ts_single", map[int]AbsInfo{
+		absX:        {Maximum: 1079},
+		absY:        {Maximum: 2339},
+		absPressure: {Maximum: 255},
+	})
+}
+
+// Prepare a started Simulator without reader and dispatcher threads,
+// the test drives handleEventB and dispatchB itself
+func newSteppedSimulator(mode TypeMode, dev *InputDevice) (*Simulator, *FakeSink) {
+	factory := NewFakeFactory(dev)
+
+	s := NewSimulatorWithFactory(factory)
+	s.sink = factory.Sink
+	s.source = factory.Source
+	s.initState(mode, 1080, 2340, dev)
+	s.syncChannel = make(chan bool, 1)
+	s.touchStart = true
+
+	return s, factory.Sink
+}
+
+func parseEvent(fields []string) (InputEvent, error) {
+	if len(fields) != 3 {
+		return InputEvent{}, fmt.Errorf("want TYPE CODE VALUE, got %q", strings.Join(fields, " "))
+	}
+
+	event := InputEvent{}
+	found := false
+	for evType, name := range eventTypeNames {
+		if name == fields[0] {
+			event.Type = evType
+			found = true
+		}
+	}
+	if !found {
+		return event, fmt.Errorf("unknown event type %s", fields[0])
+	}
+
+	found = false
+	for code, name := range eventCodeNames[event.Type] {
+		if name == fields[1] {
+			event.Code = code
+			found = true
+		}
+	}
+	if !found {
+		return event, fmt.Errorf("unknown event code %s", fields[1])
+	}
+
+	value, err := strconv.ParseInt(fields[2], 10, 32)
+	if err != nil {
+		return event, err
+	}
+	event.Value = int32(value)
+
+	return event, nil
+}
+
+func formatEvent(event InputEvent) string {
+	return fmt.Sprintf("%s %s %d", eventTypeNames[event.Type], eventCodeNames[event.Type][event.Code], event.Value)
+}
+
+// Parse frame ops: down|move ID X Y and up ID, one after another
+func parseFrame(fields []string) ([]PointerOp, error) {
+	var ops []PointerOp
+
+	for len(fields) > 0 {
+		argc := 3
+		if fields[0] == "up" {
+			argc = 1
+		}
+		if len(fields) < argc+1 {
+			return nil, fmt.Errorf("bad frame op %q", strings.Join(fields, " "))
+		}
+
+		args, err := parseInts(fields[1 : argc+1])
+		if err != nil {
+			return nil, err
+		}
+
+		switch fields[0] {
+		case "down":
+			ops = append(ops, DownOp(args[0], int32(args[1]), int32(args[2])))
+			break
+		case "move":
+			ops = append(ops, MoveOp(args[0], int32(args[1]), int32(args[2])))
+			break
+		case "up":
+			ops = append(ops, UpOp(args[0]))
+			break
+		default:
+			return nil, fmt.Errorf("bad frame op %q", fields[0])
+		}
+		fields = fields[argc+1:]
+	}
+	return ops, nil
+}
+
+func parseInts(fields []string) ([]int, error) {
+	args := make([]int, len(fields))
+	for i, field := range fields {
+		v, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+// Run injection command: inject down|move ID X Y, inject up ID,
+// inject frame OP... applying several ops in one frame
+func runInject(s *Simulator, fields []string) error {
+	if fields[0] == "frame" {
+		ops, err := parseFrame(fields[1:])
+		if err != nil {
+			return err
+		}
+		if err := s.Frame(ops...); err != nil {
+			return err
+		}
+
+		<-s.syncChannel
+		s.dispatchB()
+		return nil
+	}
+
+	args, err := parseInts(fields[1:])
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case fields[0] == "down" && len(args) == 3:
+		err = s.Down(args[0], int32(args[1]), int32(args[2]))
+	case fields[0] == "move" && len(args) == 3:
+		err = s.Move(args[0], int32(args[1]), int32(args[2]))
+	case fields[0] == "up" && len(args) == 1:
+		err = s.Up(args[0])
+	default:
+		return fmt.Errorf("bad inject command %q", strings.Join(fields, " "))
+	}
+	if err != nil {
+		return err
+	}
+
+	<-s.syncChannel
+	s.dispatchB()
+	return nil
+}
+
+// Set device state used by SYN_DROPPED resync:
+// state ABS_MT_* SLOT VALUE, state ABS_MT_SLOT VALUE, state BTN_TOUCH 0|1
+func runState(src *FakeSource, fields []string) error {
+	if len(fields) < 2 {
+		return fmt.Errorf("bad state command %q", strings.Join(fields, " "))
+	}
+
+	var typ uint16
+	if strings.HasPrefix(fields[0], "BTN_") {
+		typ = evKey
+	} else {
+		typ = evAbs
+	}
+	event, err := parseEvent([]string{eventTypeNames[typ], fields[0], fields[len(fields)-1]})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case typ == evKey && len(fields) == 2:
+		src.SetKeyState(int(event.Code), event.Value != 0)
+	case event.Code == absMtSlot && len(fields) == 2:
+		src.SetAbsValue(int(event.Code), event.Value)
+	case len(fields) == 3:
+		slot, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return err
+		}
+		src.SetSlotValue(int(event.Code), int32(slot), event.Value)
+	default:
+		return fmt.Errorf("bad state command %q", strings.Join(fields, " "))
+	}
+	return nil
+}
+
+// Replay script through the Type-B reader/dispatcher pair and render emitted frames.
+// Events of Type-A devices go through slot emulation first.
+func replayScript(t *testing.T, path string, dev *InputDevice) string {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	s, sink := newSteppedSimulator(TYPEB, dev)
+
+	var slotted *slottedSource
+	if dev.Protocol != PROTOCOLB {
+		slotted = newSlottedSource(s.source, dev)
+	}
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if fields[0] == "inject" {
+			if err := runInject(s, fields[1:]); err != nil {
+				t.Fatalf("%s:%d: %v", path, line, err)
+			}
+			continue
+		}
+		if fields[0] == "state" {
+			if err := runState(s.source.(*FakeSource), fields[1:]); err != nil {
+				t.Fatalf("%s:%d: %v", path, line, err)
+			}
+			continue
+		}
+
+		event, err := parseEvent(fields)
+		if err != nil {
+			t.Fatalf("%s:%d: %v", path, line, err)
+		}
+
+		events := []InputEvent{event}
+		if slotted != nil {
+			events = slotted.convert(event)
+		}
+
+		for _, event := range events {
+			if s.handleEventB(event) {
+				s.dispatchB()
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	for _, event := range sink.Events() {
+		out.WriteString(formatEvent(event))
+		out.WriteByte('\n')
+		if event.Type == evSyn && event.Code == synReport {
+			out.WriteString("----\n")
+		}
+	}
+	return out.String()
+}
+
+// Compare emitted events of every script in testdata/dir against its golden file
+func runGolden(t *testing.T, dir string, newDev func() *InputDevice) {
+	scripts, err := filepath.Glob(filepath.Join("testdata", dir, "*.events"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scripts) == 0 {
+		t.Fatalf("no scripts in testdata/%s", dir)
+	}
+
+	for _, script := range scripts {
+		name := strings.TrimSuffix(filepath.Base(script), ".events")
+		golden := strings.TrimSuffix(script, ".events") + ".golden"
+
+		t.Run(name, func(t *testing.T) {
+			got := replayScript(t, script, newDev())
+
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("emitted events differ from %s\n--- got ---\n%s--- want ---\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestTypeBGolden(t *testing.T) {
+	runGolden(t, "typeb", newTestDevice)
+}
+
+func TestTypeAGolden(t *testing.T) {
+	runGolden(t, "typea", newTypeATestDevice)
+}
+
+func TestSingleTouchGolden(t *testing.T) {
+	runGolden(t, "single", newSingleTestDevice)
+}
+
+func TestStopWhileQuerying(t *testing.T) {
+	dev := newTestDevice()
+	s := NewSimulatorWithFactory(NewFakeFactory(dev))
+	if err := s.Start(TYPEB, 1080, 2340, dev); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			_ = s.VirtualDevice()
+			_, _, _ = s.ToDisplay(10, 10)
+			_ = s.DefaultShape()
+		}
+	}()
+
+	s.Stop()
+	<-done
+
+	if s.VirtualDevice() != nil {
+		t.Error("stopped simulator still has a virtual device")
+	}
+}
+
+func TestDecodeInputEvents(t *testing.T) {
+	events := []InputEvent{
+		{Time: syscall.Timeval{Sec: 1, Usec: 500}, Type: evAbs, Code: absMtSlot, Value: 2},
+		{Time: syscall.Timeval{Sec: 1, Usec: 500}, Type: evAbs, Code: absMtTrackingId, Value: -1},
+		{Time: syscall.Timeval{Sec: 1, Usec: 600}, Type: evSyn, Code: synReport},
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := binary.Write(buffer, binary.LittleEndian, events); err != nil {
+		t.Fatal(err)
+	}
+	raw := buffer.Bytes()
+
+	decoded, err := decodeInputEvents(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, events) {
+		t.Errorf("decoded %+v, want %+v", decoded, events)
+	}
+
+	if _, err := decodeInputEvents(raw[:len(raw)-1]); err == nil {
+		t.Error("truncated buffer decoded without error")
+	}
+
+	decoded, err = decodeInputEvents(nil)
+	if err != nil || len(decoded) != 0 {
+		t.Errorf("empty buffer decoded to %v, %v, want no events", decoded, err)
+	}
+}
@@ -0,0 +1,257 @@
+package touchsimulation
+
+import (
+	"errors"
+	"math"
+)
+
+///----------Coordinate Transform-----------///
+
+// Rotation Display rotation, counter-clockwise from natural orientation like Android's Surface.ROTATION_*
+type Rotation int
+
+const (
+	ROTATION0 Rotation = iota
+	ROTATION90
+	ROTATION180
+	ROTATION270
+)
+
+var ErrBadRotation = errors.New("rotation must be 0, 90, 180 or 270 degrees")
+
+// RotationFromDegrees Convert 0, 90, 180 or 270 degrees to Rotation
+func RotationFromDegrees(degrees int) (Rotation, error) {
+	switch degrees {
+	case 0:
+		return ROTATION0, nil
+	case 90:
+		return ROTATION90, nil
+	case 180:
+		return ROTATION180, nil
+	case 270:
+		return ROTATION270, nil
+	}
+	return ROTATION0, ErrBadRotation
+}
+
+// Transform Maps injected display coordinates onto the touch panel: rotation
+// back to natural orientation, scaling to panel range, flips, then calibration
+type Transform struct {
+	// Width Display width in natural orientation
+	Width int32
+	// Height Display height in natural orientation
+	Height int32
+	// Rotation Current display rotation, injected coordinates follow it
+	Rotation Rotation
+	// FlipX Mirror panel X axis, for panels mounted upside down
+	FlipX bool
+	// FlipY Mirror panel Y axis
+	FlipY bool
+	// ScaleX Calibration factor applied to panel X, zero means 1
+	ScaleX float64
+	// ScaleY Calibration factor applied to panel Y, zero means 1
+	ScaleY float64
+	// OffsetX Calibration offset added to panel X, in panel units
+	OffsetX int32
+	// OffsetY Calibration offset added to panel Y, in panel units
+	OffsetY int32
+}
+
+// Size Display size in given rotation
+func (t Transform) Size() (int32, int32) {
+	if t.Rotation == ROTATION90 || t.Rotation == ROTATION270 {
+		return t.Height, t.Width
+	}
+	return t.Width, t.Height
+}
+
+// Turn coordinates of natural orientation into rotated display ones
+func (t Transform) rotate(x, y int32) (int32, int32) {
+	switch t.Rotation {
+	case ROTATION90:
+		return y, t.Width - 1 - x
+	case ROTATION180:
+		return t.Width - 1 - x, t.Height - 1 - y
+	case ROTATION270:
+		return t.Height - 1 - y, x
+	}
+	return x, y
+}
+
+// Turn coordinates of rotated display into natural orientation
+func (t Transform) unrotate(x, y int32) (int32, int32) {
+	switch t.Rotation {
+	case ROTATION90:
+		return t.Width - 1 - y, x
+	case ROTATION180:
+		return t.Width - 1 - x, t.Height - 1 - y
+	case ROTATION270:
+		return y, t.Height - 1 - x
+	}
+	return x, y
+}
+
+// Apply Map display coordinates to coordinates of device's panel, clamped to its axis ranges
+func (t Transform) Apply(dev *InputDevice, x, y int32) (int32, int32) {
+	x, y = t.unrotate(x, y)
+
+	xInfo := dev.AbsInfos[absMtPositionX]
+	yInfo := dev.AbsInfos[absMtPositionY]
+
+	x = pixelToAxis(x, t.Width, xInfo)
+	y = pixelToAxis(y, t.Height, yInfo)
+
+	if t.FlipX {
+		x = flipAxis(x, xInfo)
+	}
+	if t.FlipY {
+		y = flipAxis(y, yInfo)
+	}
+
+	x = clampAxis(calibrate(x, t.ScaleX, t.OffsetX), xInfo)
+	y = clampAxis(calibrate(y, t.ScaleY, t.OffsetY), yInfo)
+
+	return x, y
+}
+
+// Invert Map coordinates of device's panel back to display coordinates, clamped to the display
+func (t Transform) Invert(dev *InputDevice, x, y int32) (int32, int32) {
+	xInfo := dev.AbsInfos[absMtPositionX]
+	yInfo := dev.AbsInfos[absMtPositionY]
+
+	x = uncalibrate(x, t.ScaleX, t.OffsetX)
+	y = uncalibrate(y, t.ScaleY, t.OffsetY)
+
+	if t.FlipX {
+		x = flipAxis(x, xInfo)
+	}
+	if t.FlipY {
+		y = flipAxis(y, yInfo)
+	}
+
+	x = axisToPixel(x, t.Width, xInfo)
+	y = axisToPixel(y, t.Height, yInfo)
+
+	return t.rotate(x, y)
+}
+
+// Divide rounding to nearest, den must be positive
+func divRound(num, den int64) int64 {
+	if num < 0 {
+		return -((-num + den/2) / den)
+	}
+	return (num + den/2) / den
+}
+
+func clamp64(value, min, max int64) int64 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// Mirror value within axis range, in int64 so values beyond a wide range can't wrap around
+func flipAxis(value int32, info AbsInfo) int32 {
+	flipped := int64(info.Minimum) + int64(info.Maximum) - int64(value)
+	return int32(clamp64(flipped, math.MinInt32, math.MaxInt32))
+}
+
+// Clamp value into axis range
+func clampAxis(value int32, info AbsInfo) int32 {
+	if info.Maximum < info.Minimum {
+		return value
+	}
+	return int32(clamp64(int64(value), int64(info.Minimum), int64(info.Maximum)))
+}
+
+// Map display pixel [0, size) onto axis range, first and last pixel hit Minimum and Maximum exactly
+func pixelToAxis(pixel, size int32, info AbsInfo) int32 {
+	if size <= 1 || info.Maximum <= info.Minimum {
+		return clampAxis(pixel, info)
+	}
+
+	pixel64 := clamp64(int64(pixel), 0, int64(size)-1)
+	span := int64(info.Maximum) - int64(info.Minimum)
+
+	return int32(int64(info.Minimum) + divRound(pixel64*span, int64(size)-1))
+}
+
+// Map axis value back to display pixel [0, size), inverse of pixelToAxis
+func axisToPixel(value, size int32, info AbsInfo) int32 {
+	if size <= 1 || info.Maximum <= info.Minimum {
+		return value
+	}
+
+	offset := clamp64(int64(value), int64(info.Minimum), int64(info.Maximum)) - int64(info.Minimum)
+	span := int64(info.Maximum) - int64(info.Minimum)
+
+	return int32(divRound(offset*(int64(size)-1), span))
+}
+
+func calibrate(value int32, scale float64, offset int32) int32 {
+	result := float64(value)
+	if scale != 0 {
+		result *= scale
+	}
+	result += float64(offset)
+
+	return int32(clamp64(int64(math.Round(result)), math.MinInt32, math.MaxInt32))
+}
+
+func uncalibrate(value int32, scale float64, offset int32) int32 {
+	result := float64(value) - float64(offset)
+	if scale != 0 {
+		result /= scale
+	}
+
+	return int32(clamp64(int64(math.Round(result)), math.MinInt32, math.MaxInt32))
+}
+
+// SetTransform Replace whole coordinate transform, including display size
+func (s *Simulator) SetTransform(t Transform) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.transform = t
+}
+
+// ToDisplay Map coordinates of the touch device, like ones of real touches, to display coordinates
+func (s *Simulator) ToDisplay(x, y int32) (int32, int32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.touchDevice == nil {
+		return 0, 0, ErrNotStarted
+	}
+
+	x, y = s.transform.Invert(s.touchDevice, x, y)
+	return x, y, nil
+}
+
+// Transform Fetch current coordinate transform
+func (s *Simulator) Transform() Transform {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.transform
+}
+
+// SetDisplaySize Change display size in natural orientation, like after a resolution switch
+func (s *Simulator) SetDisplaySize(width, height int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.transform.Width = width
+	s.transform.Height = height
+}
+
+// SetRotation Follow display rotation, later injected coordinates are taken in the rotated display
+func (s *Simulator) SetRotation(rotation Rotation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.transform.Rotation = rotation
+}
@@ -0,0 +1,166 @@
+package touchsimulation
+
+import (
+	"testing"
+)
+
+// Panel with given X/Y axis ranges
+func newRangeDevice(xInfo, yInfo AbsInfo) *InputDevice {
+	return NewFakeTouchDevice("test_range", map[int]AbsInfo{
+		absMtSlot:       {Maximum: 9},
+		absMtPositionX:  xInfo,
+		absMtPositionY:  yInfo,
+		absMtTrackingId: {Maximum: 65535},
+	})
+}
+
+func TestPixelToAxisEdges(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int32
+		info  AbsInfo
+		pixel int32
+		want  int32
+	}{
+		{"first pixel", 1080, AbsInfo{Maximum: 1079}, 0, 0},
+		{"last pixel", 1080, AbsInfo{Maximum: 1079}, 1079, 1079},
+		{"last pixel upscaled", 1440, AbsInfo{Maximum: 4095}, 1439, 4095},
+		{"last pixel downscaled", 3216, AbsInfo{Maximum: 1439}, 3215, 1439},
+		{"middle pixel upscaled", 1441, AbsInfo{Maximum: 4096}, 720, 2048},
+		{"offset range first", 1080, AbsInfo{Minimum: 100, Maximum: 1179}, 0, 100},
+		{"offset range last", 1080, AbsInfo{Minimum: 100, Maximum: 1179}, 1079, 1179},
+		{"negative range last", 1080, AbsInfo{Minimum: -540, Maximum: 539}, 1079, 539},
+		{"high resolution last", 3216, AbsInfo{Maximum: 0x7ffffffe}, 3215, 0x7ffffffe},
+		{"high resolution first", 3216, AbsInfo{Minimum: -0x7fffffff, Maximum: 0x7fffffff}, 0, -0x7fffffff},
+		{"left of display", 1080, AbsInfo{Maximum: 1079}, -5, 0},
+		{"right of display", 1080, AbsInfo{Maximum: 1079}, 1080, 1079},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := pixelToAxis(test.pixel, test.size, test.info)
+			if got != test.want {
+				t.Errorf("pixelToAxis(%d, %d, %+v) = %d, want %d", test.pixel, test.size, test.info, got, test.want)
+			}
+		})
+	}
+}
+
+func TestAxisToPixelRoundTrip(t *testing.T) {
+	infos := []AbsInfo{
+		{Maximum: 1079},
+		{Maximum: 4095},
+		{Minimum: 100, Maximum: 1179},
+		{Minimum: -0x7fffffff, Maximum: 0x7fffffff},
+	}
+
+	for _, info := range infos {
+		for _, pixel := range []int32{0, 1, 539, 1078, 1079} {
+			axis := pixelToAxis(pixel, 1080, info)
+			if got := axisToPixel(axis, 1080, info); got != pixel {
+				t.Errorf("%+v: pixel %d -> axis %d -> pixel %d", info, pixel, axis, got)
+			}
+		}
+	}
+}
+
+func TestTransformRotation(t *testing.T) {
+	dev := newRangeDevice(AbsInfo{Maximum: 1079}, AbsInfo{Maximum: 2339})
+
+	tests := []struct {
+		rotation Rotation
+		x, y     int32
+		wantX    int32
+		wantY    int32
+	}{
+		{ROTATION0, 0, 0, 0, 0},
+		{ROTATION0, 1079, 2339, 1079, 2339},
+		{ROTATION90, 0, 0, 1079, 0},
+		{ROTATION90, 2339, 1079, 0, 2339},
+		{ROTATION180, 0, 0, 1079, 2339},
+		{ROTATION180, 1079, 2339, 0, 0},
+		{ROTATION270, 0, 0, 0, 2339},
+		{ROTATION270, 2339, 1079, 1079, 0},
+	}
+
+	for _, test := range tests {
+		tr := Transform{Width: 1080, Height: 2340, Rotation: test.rotation}
+
+		x, y := tr.Apply(dev, test.x, test.y)
+		if x != test.wantX || y != test.wantY {
+			t.Errorf("rotation %d: Apply(%d, %d) = (%d, %d), want (%d, %d)", test.rotation, test.x, test.y, x, y, test.wantX, test.wantY)
+		}
+
+		x, y = tr.Invert(dev, x, y)
+		if x != test.x || y != test.y {
+			t.Errorf("rotation %d: Invert gave (%d, %d), want (%d, %d)", test.rotation, x, y, test.x, test.y)
+		}
+	}
+}
+
+func TestTransformFlipAndCalibration(t *testing.T) {
+	dev := newRangeDevice(AbsInfo{Minimum: 10, Maximum: 1089}, AbsInfo{Maximum: 2339})
+
+	tr := Transform{Width: 1080, Height: 2340, FlipX: true}
+	if x, _ := tr.Apply(dev, 0, 0); x != 1089 {
+		t.Errorf("flipped first pixel = %d, want 1089", x)
+	}
+	if x, _ := tr.Apply(dev, 1079, 0); x != 10 {
+		t.Errorf("flipped last pixel = %d, want 10", x)
+	}
+
+	tr = Transform{Width: 1080, Height: 2340, OffsetY: 20}
+	if _, y := tr.Apply(dev, 0, 2339); y != 2339 {
+		t.Errorf("calibrated last pixel = %d, want clamped 2339", y)
+	}
+	if x, y := tr.Invert(dev, 10, 20); x != 0 || y != 0 {
+		t.Errorf("Invert(10, 20) = (%d, %d), want (0, 0)", x, y)
+	}
+}
+
+func TestRescaleAxisEdges(t *testing.T) {
+	from := AbsInfo{Maximum: 4095}
+	to := AbsInfo{Minimum: 5, Maximum: 1084}
+
+	for _, test := range []struct{ value, want int32 }{
+		{0, 5},
+		{4095, 1084},
+		{-10, 5},
+		{5000, 1084},
+	} {
+		if got := rescaleAxis(test.value, from, to); got != test.want {
+			t.Errorf("rescaleAxis(%d) = %d, want %d", test.value, got, test.want)
+		}
+	}
+}
+
+func TestRotationFromDegrees(t *testing.T) {
+	for degrees, want := range map[int]Rotation{0: ROTATION0, 90: ROTATION90, 180: ROTATION180, 270: ROTATION270} {
+		if got, err := RotationFromDegrees(degrees); err != nil || got != want {
+			t.Errorf("RotationFromDegrees(%d) = %d, %v, want %d", degrees, got, err, want)
+		}
+	}
+	if _, err := RotationFromDegrees(45); err != ErrBadRotation {
+		t.Errorf("RotationFromDegrees(45) error = %v, want ErrBadRotation", err)
+	}
+}
+
+func TestTouchRangeFields(t *testing.T) {
+	dev := newRangeDevice(AbsInfo{Minimum: 10, Maximum: 1089}, AbsInfo{Minimum: -5, Maximum: 2339})
+
+	if dev.TouchXMin != 10 || dev.TouchXMax != 1089 || dev.TouchYMin != -5 || dev.TouchYMax != 2339 {
+		t.Errorf("touch ranges X %d..%d Y %d..%d, want X 10..1089 Y -5..2339", dev.TouchXMin, dev.TouchXMax, dev.TouchYMin, dev.TouchYMax)
+	}
+}
+
+func TestTransformFlipWideRange(t *testing.T) {
+	dev := newRangeDevice(AbsInfo{Maximum: 0x7fffffff}, AbsInfo{Minimum: -0x80000000, Maximum: -1})
+
+	tr := Transform{Width: 1080, Height: 2340, FlipX: true, FlipY: true}
+	if x, y := tr.Invert(dev, -10, 10); x != 1079 || y != 0 {
+		t.Errorf("Invert(-10, 10) beyond flipped ranges = (%d, %d), want (1079, 0)", x, y)
+	}
+	if x, y := tr.Apply(dev, 0, 2339); x != 0x7fffffff || y != -0x80000000 {
+		t.Errorf("Apply(0, 2339) = (%d, %d), want (%d, %d)", x, y, 0x7fffffff, -0x80000000)
+	}
+}
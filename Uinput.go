@@ -1,4 +1,4 @@
-package main
+package touchsimulation
 
 import (
 	"bytes"
@@ -17,12 +17,15 @@ import (
 type InputDevice struct {
 	Name           string
 	Path           string
+	SysPath        string
 	Slots          int32
+	Protocol       TouchProtocol
+	Direct         bool
 	Version        int32
-	TouchXMin      int32
-	TouchXMax      int32
-	TouchYMin      int32
-	TouchYMax      int32
+	TouchXMin      int32 // ABS_MT_POSITION_X minimum
+	TouchXMax      int32 // ABS_MT_POSITION_X maximum, inclusive
+	TouchYMin      int32 // ABS_MT_POSITION_Y minimum
+	TouchYMax      int32 // ABS_MT_POSITION_Y maximum, inclusive
 	Grabed         bool
 	hasTouchMajor  bool
 	hasTouchMinor  bool
@@ -30,6 +33,7 @@ type InputDevice struct {
 	hasWidthMinor  bool
 	hasOrientation bool
 	hasPressure    bool
+	emulatedAbs    map[int]bool
 	Dbits          *[evCnt / 8]byte
 	AbsBits        *[absCnt / 8]byte
 	KeyBits        *[keyCnt / 8]byte
@@ -56,18 +60,61 @@ func (dev *InputDevice) hasAbs(key int) bool {
 	return dev.AbsBits[key/8]&(1<<uint(key%8)) != 0
 }
 
+// Store AbsInfo of given Abs Key and derive touch ranges from it
+func (dev *InputDevice) setAbsInfo(key int, absInfo AbsInfo) {
+	switch key {
+	case absMtSlot:
+		dev.Slots = absInfo.Maximum + 1
+		break
+	case absMtTrackingId:
+		if absInfo.Maximum == absInfo.Minimum {
+			absInfo.Minimum = -1
+			absInfo.Maximum = 0xFFFF
+		}
+		break
+	case absMtPositionX:
+		dev.TouchXMin = absInfo.Minimum
+		dev.TouchXMax = absInfo.Maximum
+		break
+	case absMtPositionY:
+		dev.TouchYMin = absInfo.Minimum
+		dev.TouchYMax = absInfo.Maximum
+		break
+	}
+
+	if dev.AbsInfos == nil {
+		dev.AbsInfos = make(map[int]AbsInfo)
+	}
+	dev.AbsInfos[key] = absInfo
+}
+
+// Refresh optional contact capabilities from AbsBits
+func (dev *InputDevice) updateCaps() {
+	dev.hasTouchMajor = dev.hasAbs(absMtTouchMajor)
+	dev.hasTouchMinor = dev.hasAbs(absMtTouchMinor)
+	dev.hasWidthMajor = dev.hasAbs(absMtWidthMajor)
+	dev.hasWidthMinor = dev.hasAbs(absMtWidthMinor)
+	dev.hasOrientation = dev.hasAbs(absMtOrientation)
+	dev.hasPressure = dev.hasAbs(absMtPressure)
+}
+
 // Fetch Active Input Devices
 func getInputDevices() ([]*InputDevice, error) {
 	paths, err := filepath.Glob("/dev/input/event*")
 	if err != nil {
 		return nil, err
 	}
+	sortEventPaths(paths)
 
 	var ids []*InputDevice
+	var permErr error
 
 	for _, path := range paths {
 		if isCharDevice(path) {
 			inDev, err := os.OpenFile(path, syscall.O_RDONLY|syscall.O_NONBLOCK, 0666)
+			if errors.Is(err, os.ErrPermission) && permErr == nil {
+				permErr = err
+			}
 			if err == nil {
 				// Read Ev data
 				dBits := new([evCnt / 8]byte)
@@ -97,15 +144,7 @@ func getInputDevices() ([]*InputDevice, error) {
 					continue
 				}
 
-				// Devices with ABS_MT_SLOT - 1 aren't MT devices, libevdev:libevdev.c#L319
-				if !hasSpecificAbs(absBits, absMtSlot-1) &&
-					hasSpecificAbs(absBits, absMtSlot) &&
-					hasSpecificAbs(absBits, absMtTrackingId) &&
-					hasSpecificAbs(absBits, absMtPositionX) &&
-					hasSpecificAbs(absBits, absMtPositionY) &&
-					hasSpecificProp(propBits, inputPropDirect) &&
-					hasSpecificKey(keyBits, btnTouch) {
-
+				if _, ok := classifyProtocol(absBits, keyBits); ok {
 					id := &InputDevice{
 						Path:     path,
 						File:     inDev,
@@ -126,30 +165,7 @@ func getInputDevices() ([]*InputDevice, error) {
 							continue
 						}
 
-						switch i {
-						case absMtSlot:
-							id.Slots = absInfo.Maximum + 1
-							break
-						case absMtTrackingId:
-							if absInfo.Maximum == absInfo.Minimum {
-								absInfo.Minimum = -1
-								absInfo.Maximum = 0xFFFF
-							}
-							break
-						case absMtPositionX:
-							id.TouchXMin = absInfo.Minimum
-							id.TouchXMax = absInfo.Maximum - absInfo.Minimum + 1
-							break
-						case absMtPositionY:
-							id.TouchYMin = absInfo.Minimum
-							id.TouchYMax = absInfo.Maximum - absInfo.Minimum + 1
-							break
-						}
-
-						if id.AbsInfos == nil {
-							id.AbsInfos = make(map[int]AbsInfo)
-						}
-						id.AbsInfos[i] = absInfo
+						id.setAbsInfo(i, absInfo)
 					}
 
 					// Read InputID
@@ -165,12 +181,7 @@ func getInputDevices() ([]*InputDevice, error) {
 					}
 
 					id.Name = getDeviceName(inDev)
-					id.hasTouchMajor = id.hasAbs(absMtTouchMajor)
-					id.hasTouchMinor = id.hasAbs(absMtTouchMinor)
-					id.hasWidthMajor = id.hasAbs(absMtWidthMajor)
-					id.hasWidthMinor = id.hasAbs(absMtWidthMinor)
-					id.hasOrientation = id.hasAbs(absMtOrientation)
-					id.hasPressure = id.hasAbs(absMtPressure)
+					id.classify()
 
 					ids = append(ids, id)
 				}
@@ -179,9 +190,13 @@ func getInputDevices() ([]*InputDevice, error) {
 	}
 
 	if ids != nil && len(ids) > 0 {
+		sortDevices(ids)
 		return ids, nil
+	} else if permErr != nil {
+		// Touch screen may be among the devices we weren't allowed to open
+		return nil, &DeviceError{Kind: ErrNoDevice, Path: "/dev/input", Err: permErr}
 	} else {
-		return nil, errors.New("devices are not found")
+		return nil, ErrNoDevice
 	}
 }
 
@@ -256,227 +271,45 @@ func getDeviceName(f *os.File) string {
 
 // Create new Type-B UInput device with details given from Event device
 func newTypeBDevSame(inputDev *InputDevice) (*InputDevice, error) {
-	//Open UInput
-	deviceFile, err := os.OpenFile("/dev/uinput", syscall.O_WRONLY|syscall.O_NONBLOCK, 0660)
-	if err != nil {
-		return nil, err
-	}
-
-	//Setup EV_KEY
-	err = ioctl(deviceFile.Fd(), UISETEVBIT(), evKey)
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-	for i := 0; i <= keyMax; i++ {
-		if !hasSpecificKey(inputDev.KeyBits, i) {
-			continue
-		}
-
-		// btnTouch
-		err = ioctl(deviceFile.Fd(), UISETKEYBIT(), uintptr(i))
-		if err != nil {
-			_ = releaseDevice(deviceFile)
-			_ = deviceFile.Close()
-			return nil, err
-		}
-	}
-
-	//Setup EV_ABS
-	err = ioctl(deviceFile.Fd(), UISETEVBIT(), evAbs)
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-
-	var absMins [absCnt]int32
-	var absMaxs [absCnt]int32
-	var absFuzz [absCnt]int32
-	var absFlat [absCnt]int32
-
-	for i := 0; i <= absMax; i++ {
-		if !hasSpecificAbs(inputDev.AbsBits, i) {
-			continue
-		}
-
-		err = ioctl(deviceFile.Fd(), UISETABSBIT(), uintptr(i))
-		if err != nil {
-			_ = releaseDevice(deviceFile)
-			_ = deviceFile.Close()
-			return nil, err
-		}
-
-		absMins[i] = inputDev.AbsInfos[i].Minimum
-		absMaxs[i] = inputDev.AbsInfos[i].Maximum
-		absFuzz[i] = inputDev.AbsInfos[i].Fuzz
-		absFlat[i] = inputDev.AbsInfos[i].Flat
-	}
-
-	//Setup INPUT_PROP_DIRECT
-	for i := 0; i <= inputPropMax; i++ {
-		if !hasSpecificProp(inputDev.PropBits, i) {
-			continue
-		}
-
-		// inputPropDirect
-		err = ioctl(deviceFile.Fd(), UISETPROPBIT(), uintptr(i))
-		if err != nil {
-			_ = releaseDevice(deviceFile)
-			_ = deviceFile.Close()
-			return nil, err
-		}
-	}
-
-	//Setup User Device
-	effectsMax := uint32(0)
-	if hasSpecificType(inputDev.Dbits, evFF) {
-		effectsMax = 10
-	}
-
-	newDeviceName := inputDev.Name + "2"
-	uiDev := UinputUserDev{
-		Name: toUInputName([]byte(newDeviceName)),
-		ID: InputID{
-			BusType: inputDev.IID.BusType,
-			Vendor:  inputDev.IID.Vendor,
-			Product: inputDev.IID.Product,
-			Version: inputDev.IID.Version,
-		},
-		EffectsMax: effectsMax,
-		AbsMax:     absMaxs,
-		AbsMin:     absMins,
-		AbsFuzz:    absFuzz,
-		AbsFlat:    absFlat,
-	}
-
-	//Write to Input Sub-System
-	_, err = deviceFile.Write(uInputDevToBytes(uiDev))
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-
-	//Declare Input Device
-	err = createDevice(deviceFile)
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-
-	//Stop Primary Touch Device
-	err = inputDev.Grab()
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-
-	time.Sleep(time.Millisecond * 200)
-
-	return &InputDevice{
-		File: deviceFile,
-		Name: newDeviceName,
-	}, nil
+	return CreateDevice(typeBSpecSame(inputDev))
 }
 
 // Create new Type-A UInput device with details given from Event device
 func newTypeADevSame(inputDev *InputDevice) (*InputDevice, error) {
-	//Open UInput
-	deviceFile, err := os.OpenFile("/dev/uinput", syscall.O_WRONLY|syscall.O_NONBLOCK, 0660)
-	if err != nil {
-		return nil, err
-	}
+	return CreateDevice(typeASpecSame(inputDev))
+}
 
-	//Setup EV_KEY
-	err = ioctl(deviceFile.Fd(), UISETEVBIT(), evKey)
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-	for i := 0; i <= keyMax; i++ {
-		if !hasSpecificKey(inputDev.KeyBits, i) {
-			continue
-		}
+// Create new Type-A UInput device with random details
+func newTypeADevRandom(inputDev *InputDevice) (*InputDevice, error) {
+	return CreateDevice(typeASpecRandom(inputDev))
+}
 
-		// btnTouch
-		err = ioctl(deviceFile.Fd(), UISETKEYBIT(), uintptr(i))
-		if err != nil {
-			_ = releaseDevice(deviceFile)
-			_ = deviceFile.Close()
-			return nil, err
+// Describe UInput device to the kernel. Uses UI_DEV_SETUP and UI_ABS_SETUP,
+// which carry full AbsInfo including Resolution, on kernels having uinput v5;
+// falls back to writing legacy uinput_user_dev otherwise.
+func setupDevice(f *os.File, name string, id InputID, effectsMax uint32, absInfos map[int]AbsInfo) error {
+	if uinputVersion(f) >= 5 {
+		err := setupDeviceModern(f, name, id, effectsMax, absInfos)
+		if err != syscall.EINVAL && err != syscall.ENOTTY {
+			return err
 		}
 	}
 
-	//Setup EV_ABS
-	err = ioctl(deviceFile.Fd(), UISETEVBIT(), evAbs)
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-
 	var absMins [absCnt]int32
 	var absMaxs [absCnt]int32
 	var absFuzz [absCnt]int32
 	var absFlat [absCnt]int32
 
-	for i := 0; i <= absMax; i++ {
-		if !hasSpecificAbs(inputDev.AbsBits, i) {
-			continue
-		}
-
-		// Make Sure only TypeA enabled ABS got set
-		// absMtPositionX, absMtPositionY, absMtTrackingId
-		if i == absMtPositionX || i == absMtPositionY || i == absMtTrackingId {
-			err = ioctl(deviceFile.Fd(), UISETABSBIT(), uintptr(i))
-			if err != nil {
-				_ = releaseDevice(deviceFile)
-				_ = deviceFile.Close()
-				return nil, err
-			}
-
-			absMins[i] = inputDev.AbsInfos[i].Minimum
-			absMaxs[i] = inputDev.AbsInfos[i].Maximum
-			absFuzz[i] = inputDev.AbsInfos[i].Fuzz
-			absFlat[i] = inputDev.AbsInfos[i].Flat
-		}
-	}
-
-	//Setup INPUT_PROP_DIRECT
-	for i := 0; i <= inputPropMax; i++ {
-		if !hasSpecificProp(inputDev.PropBits, i) {
-			continue
-		}
-
-		// inputPropDirect
-		err = ioctl(deviceFile.Fd(), UISETPROPBIT(), uintptr(i))
-		if err != nil {
-			_ = releaseDevice(deviceFile)
-			_ = deviceFile.Close()
-			return nil, err
-		}
+	for code, absInfo := range absInfos {
+		absMins[code] = absInfo.Minimum
+		absMaxs[code] = absInfo.Maximum
+		absFuzz[code] = absInfo.Fuzz
+		absFlat[code] = absInfo.Flat
 	}
 
-	//Setup User Device
-	effectsMax := uint32(0)
-	if hasSpecificType(inputDev.Dbits, evFF) {
-		effectsMax = 10
-	}
-
-	newDeviceName := inputDev.Name + "2"
 	uiDev := UinputUserDev{
-		Name: toUInputName([]byte(newDeviceName)),
-		ID: InputID{
-			BusType: inputDev.IID.BusType,
-			Vendor:  inputDev.IID.Vendor,
-			Product: inputDev.IID.Product,
-			Version: inputDev.IID.Version,
-		},
+		Name:       toUInputName([]byte(name)),
+		ID:         id,
 		EffectsMax: effectsMax,
 		AbsMax:     absMaxs,
 		AbsMin:     absMins,
@@ -484,158 +317,31 @@ func newTypeADevSame(inputDev *InputDevice) (*InputDevice, error) {
 		AbsFlat:    absFlat,
 	}
 
-	//Write to Input Sub-System
-	_, err = deviceFile.Write(uInputDevToBytes(uiDev))
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-
-	//Declare Input Device
-	err = createDevice(deviceFile)
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-
-	//Stop Primary Touch Device
-	err = inputDev.Grab()
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-
-	time.Sleep(time.Millisecond * 200)
-
-	return &InputDevice{
-		File: deviceFile,
-		Name: newDeviceName,
-	}, nil
+	_, err := f.Write(uInputDevToBytes(uiDev))
+	return err
 }
 
-// Create new Type-A UInput device with random details
-func newTypeADevRandom(inputDev *InputDevice) (*InputDevice, error) {
-	//Open UInput
-	deviceFile, err := os.OpenFile("/dev/uinput", syscall.O_WRONLY|syscall.O_NONBLOCK, 0660)
-	if err != nil {
-		return nil, err
-	}
-
-	//Setup EV_KEY
-	err = ioctl(deviceFile.Fd(), UISETEVBIT(), evKey)
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-	err = ioctl(deviceFile.Fd(), UISETKEYBIT(), btnTouch)
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-
-	//Setup EV_ABS
-	err = ioctl(deviceFile.Fd(), UISETEVBIT(), evAbs)
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-	err = ioctl(deviceFile.Fd(), UISETABSBIT(), absMtPositionX)
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-	err = ioctl(deviceFile.Fd(), UISETABSBIT(), absMtPositionY)
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-	err = ioctl(deviceFile.Fd(), UISETABSBIT(), absMtTrackingId)
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-	err = ioctl(deviceFile.Fd(), UISETPROPBIT(), inputPropDirect)
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
+// Describe UInput device with UI_ABS_SETUP per axis followed by UI_DEV_SETUP
+func setupDeviceModern(f *os.File, name string, id InputID, effectsMax uint32, absInfos map[int]AbsInfo) error {
+	for code, absInfo := range absInfos {
+		absSetup := UinputAbsSetup{
+			Code:    uint16(code),
+			AbsInfo: absInfo,
+		}
 
-	//Setup User Device
-	var absMin [absCnt]int32
-	absMin[absMtPositionX] = inputDev.AbsInfos[absMtPositionX].Minimum
-	absMin[absMtPositionY] = inputDev.AbsInfos[absMtPositionY].Minimum
-	absMin[absMtTrackingId] = 0
-
-	var absMax [absCnt]int32
-	absMax[absMtPositionX] = inputDev.AbsInfos[absMtPositionX].Maximum
-	absMax[absMtPositionY] = inputDev.AbsInfos[absMtPositionY].Maximum
-	absMax[absMtTrackingId] = inputDev.Slots - 1
-
-	newDeviceName := randStringBytes(7)
-	newVendor := randUInt16Num(0x2000)
-	newProduct := randUInt16Num(0x2000)
-	newVersion := randUInt16Num(0x20)
-
-	effectsMax := uint32(0)
-	if hasSpecificType(inputDev.Dbits, evFF) {
-		effectsMax = 10
+		err := ioctl(f.Fd(), UIABSSETUP(), uintptr(unsafe.Pointer(&absSetup)))
+		if err != nil {
+			return err
+		}
 	}
 
-	uiDev := UinputUserDev{
-		Name: toUInputName([]byte(newDeviceName)),
-		ID: InputID{
-			BusType: 0,
-			Vendor:  newVendor,
-			Product: newProduct,
-			Version: newVersion,
-		},
+	setup := UinputSetup{
+		ID:         id,
+		Name:       toUInputName([]byte(name)),
 		EffectsMax: effectsMax,
-		AbsMax:     absMax,
-		AbsMin:     absMin,
-		AbsFuzz:    [absCnt]int32{},
-		AbsFlat:    [absCnt]int32{},
-	}
-
-	//Write to Input Sub-System
-	_, err = deviceFile.Write(uInputDevToBytes(uiDev))
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-
-	//Declare Input Device
-	err = createDevice(deviceFile)
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
 	}
 
-	//Stop Primary Touch Device
-	err = inputDev.Grab()
-	if err != nil {
-		_ = releaseDevice(deviceFile)
-		_ = deviceFile.Close()
-		return nil, err
-	}
-
-	time.Sleep(time.Millisecond * 200)
-
-	return &InputDevice{
-		File: deviceFile,
-		Name: newDeviceName,
-	}, nil
+	return ioctl(f.Fd(), UIDEVSETUP(), uintptr(unsafe.Pointer(&setup)))
 }
 
 func toUInputName(name []byte) [uinputMaxNameSize]byte {
@@ -654,6 +360,42 @@ func createDevice(f *os.File) (err error) {
 	return ioctl(f.Fd(), UIDEVCREATE(), uintptr(0))
 }
 
+// Ask uinput for the sysfs name of created device and fill SysPath and Path
+// with its sysfs directory and /dev/input event node
+func (dev *InputDevice) resolveNode() error {
+	sysName := make([]byte, 64)
+
+	err := ioctl(dev.File.Fd(), UIGETSYSNAME(len(sysName)), uintptr(unsafe.Pointer(&sysName[0])))
+	if err != nil {
+		return err
+	}
+
+	dev.SysPath = filepath.Join("/sys/devices/virtual/input", string(bytes.TrimRight(sysName, "\x00")))
+
+	matches, err := filepath.Glob(filepath.Join(dev.SysPath, "event[0-9]*"))
+	if err != nil {
+		return err
+	}
+	if len(matches) < 1 {
+		return errors.New("event node is not found")
+	}
+	devPath := filepath.Join("/dev/input", filepath.Base(matches[0]))
+
+	//Wait for udev/ueventd creating the node
+	for i := 0; i < 100; i++ {
+		if _, err = os.Stat(devPath); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		return err
+	}
+
+	dev.Path = devPath
+	return nil
+}
+
 func releaseDevice(f *os.File) (err error) {
 	return ioctl(f.Fd(), UIDEVDESTROY(), uintptr(0))
 }
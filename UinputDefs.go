@@ -1,4 +1,4 @@
-package main
+package touchsimulation
 
 import (
 	"syscall"
@@ -16,6 +16,9 @@ const (
 	synReport        = 0
 	synMtReport      = 2
 	synDropped       = 3
+	absX             = 0x00
+	absY             = 0x01
+	absPressure      = 0x18
 	absMtSlot        = 0x2f
 	absMtTouchMajor  = 0x30
 	absMtTouchMinor  = 0x31
@@ -94,8 +97,12 @@ func EVIOCGABS(abs int) int {
 	return _IOR('E', 0x40+abs, 24) //sizeof(struct input_absinfo)
 }
 
-func EVIOCGKEY() int {
-	return _IOC(iocRead, 'E', 0x18, keyMax)
+func EVIOCGKEY(len int) int {
+	return _IOC(iocRead, 'E', 0x18, len)
+}
+
+func EVIOCGMTSLOTS(len int) int {
+	return _IOC(iocRead, 'E', 0x0a, len)
 }
 
 func EVIOCGBIT(ev, len int) int {
@@ -157,7 +164,31 @@ type UinputUserDev struct {
 	AbsFlat    [absCnt]int32
 }
 
+type UinputSetup struct {
+	ID         InputID
+	Name       [uinputMaxNameSize]byte
+	EffectsMax uint32
+}
+
+type UinputAbsSetup struct {
+	Code    uint16
+	_       uint16 // Padding
+	AbsInfo AbsInfo
+}
+
 // Ref: uinput.h
+func UIDEVSETUP() int {
+	return _IOW('U', 3, 92) //sizeof(struct uinput_setup)
+}
+
+func UIABSSETUP() int {
+	return _IOW('U', 4, 28) //sizeof(struct uinput_abs_setup)
+}
+
+func UIGETVERSION() int {
+	return _IOR('U', 45, 4) //sizeof(unsigned int)
+}
+
 func UISETEVBIT() int {
 	return _IOW('U', 100, 4) //sizeof(int)
 }
@@ -174,6 +205,10 @@ func UISETPROPBIT() int {
 	return _IOW('U', 110, 4) //sizeof(int)
 }
 
+func UISETFFBIT() int {
+	return _IOW('U', 107, 4) //sizeof(int)
+}
+
 func UIDEVCREATE() int {
 	return _IOC(iocNone, 'U', 1, 0)
 }
@@ -181,3 +216,7 @@ func UIDEVCREATE() int {
 func UIDEVDESTROY() int {
 	return _IOC(iocNone, 'U', 2, 0)
 }
+
+func UIGETSYSNAME(len int) int {
+	return _IOC(iocRead, 'U', 44, len)
+}
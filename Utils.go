@@ -1,4 +1,4 @@
-package main
+package touchsimulation
 
 import (
 	"math/rand"
@@ -24,8 +24,3 @@ func randIntegerNum(n int) int {
 func randUInt16Num(n int) uint16 {
 	return uint16(rand.Intn(n))
 }
-
-func getRandomShift() int32 {
-	//rand(max - min) - min, Range: [min, max]
-	return rand.Int31n(40) - 20 //[-20, 20]
-}
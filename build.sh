@@ -1,4 +1,4 @@
 #!/bin/bash
 go clean
-GOOS=linux CGO_ENABLED=0 GOARCH=arm GOARM=7 go build -trimpath -gcflags "-trimpath $PWD" -ldflags "-s -w" -o "bin/TouchTest"
-GOOS=linux CGO_ENABLED=0 GOARCH=arm64 go build -trimpath -gcflags "-trimpath $PWD" -ldflags "-s -w" -o "bin/TouchTest64"
\ No newline at end of file
+GOOS=linux CGO_ENABLED=0 GOARCH=arm GOARM=7 go build -trimpath -gcflags "-trimpath $PWD" -ldflags "-s -w" -o "bin/TouchTest" ./cmd/touchtest
+GOOS=linux CGO_ENABLED=0 GOARCH=arm64 go build -trimpath -gcflags "-trimpath $PWD" -ldflags "-s -w" -o "bin/TouchTest64" ./cmd/touchtest
\ No newline at end of file
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	ts "kuldippatel.dev/touchsimulation"
+	"kuldippatel.dev/touchsimulation/gesture"
+)
+
+const (
+	x  = 746
+	y  = 1064
+	nx = 400
+	ny = 1408
+)
+
+// Swipe fake touch between two points within given duration at given sample rate
+func Swipe(sim *ts.Simulator, StartX, StartY, EndX, EndY int32, duration time.Duration, rate int) {
+	err := gesture.Swipe(sim, 0, gesture.Point{X: StartX, Y: StartY}, gesture.Point{X: EndX, Y: EndY}, gesture.Options{
+		Duration: duration,
+		Easing:   gesture.EaseInOut,
+		Rate:     rate,
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// Parse point given as x,y
+func parsePoint(value string) (gesture.Point, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return gesture.Point{}, fmt.Errorf("point %q is not x,y", value)
+	}
+
+	x, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 32)
+	if err != nil {
+		return gesture.Point{}, err
+	}
+	y, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 32)
+	if err != nil {
+		return gesture.Point{}, err
+	}
+
+	return gesture.Point{X: int32(x), Y: int32(y)}, nil
+}
+
+// Gestures runGesture knows
+var gestureNames = map[string]bool{
+	"tap": true, "doubletap": true, "multitap": true, "longpress": true, "swipe": true, "drag": true, "fling": true,
+	"humanswipe": true, "pinch": true, "rotate": true,
+}
+
+// Spans and angle of two finger gestures
+type twoFingerArgs struct {
+	fromSpan float64
+	toSpan   float64
+	radius   float64
+	angle    float64
+}
+
+// Run named gesture with fake pointer 0, two finger ones also use pointer 1 and take from as center
+func runGesture(sim *ts.Simulator, name string, from, to gesture.Point, count int, interval, hold time.Duration, velocity float64, human gesture.Humanize, two twoFingerArgs, opts gesture.Options) error {
+	switch name {
+	case "tap":
+		return gesture.Tap(sim, 0, from, opts)
+	case "doubletap":
+		return gesture.DoubleTap(sim, 0, from, opts)
+	case "multitap":
+		return gesture.MultiTap(sim, 0, from, count, interval, opts)
+	case "longpress":
+		return gesture.LongPress(sim, 0, from, opts)
+	case "swipe":
+		return gesture.Swipe(sim, 0, from, to, opts)
+	case "humanswipe":
+		return gesture.HumanSwipe(sim, 0, from, to, human, opts)
+	case "drag":
+		return gesture.DragAndHold(sim, 0, from, to, hold, hold, opts)
+	case "pinch":
+		return gesture.Pinch(sim, 0, 1, from, two.fromSpan, two.toSpan, opts)
+	case "rotate":
+		return gesture.Rotate(sim, 0, 1, from, two.radius, two.angle, opts)
+	case "fling":
+		return gesture.Fling(sim, 0, from, to, velocity, opts)
+	}
+	return fmt.Errorf("unknown gesture %q", name)
+}
+
+// Build device filter from selection flags
+func parseFilter(path, name, vendor, product string) (ts.DeviceFilter, error) {
+	filter := ts.DeviceFilter{Path: path}
+
+	if name != "" {
+		re, err := regexp.Compile(name)
+		if err != nil {
+			return filter, err
+		}
+		filter.Name = re
+	}
+
+	if vendor != "" {
+		id, err := strconv.ParseUint(vendor, 0, 16)
+		if err != nil {
+			return filter, err
+		}
+		filter.Vendor = uint16(id)
+	}
+
+	if product != "" {
+		id, err := strconv.ParseUint(product, 0, 16)
+		if err != nil {
+			return filter, err
+		}
+		filter.Product = uint16(id)
+	}
+
+	return filter, nil
+}
+
+// Pick touch device matching filter, asking the user when choose is set and several match
+func pickDevice(factory ts.DeviceFactory, filter ts.DeviceFilter, choose bool) (*ts.InputDevice, error) {
+	tDevs, err := factory.InputDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := ts.SelectDevices(tDevs, filter)
+	if len(selected) < 1 {
+		ts.CloseDevices(tDevs, nil)
+		return nil, ts.ErrNoDevice
+	}
+
+	tDev := selected[0]
+	if choose && len(selected) > 1 {
+		tDev, err = ts.PromptDevice(selected, os.Stdin, os.Stdout)
+		if err != nil {
+			ts.CloseDevices(tDevs, nil)
+			return nil, err
+		}
+	}
+
+	ts.CloseDevices(tDevs, tDev)
+
+	return tDev, nil
+}
+
+// Explain setup failure along with what may fix it
+func diagnose(err error) string {
+	hint := ""
+
+	switch {
+	case errors.Is(err, os.ErrPermission):
+		hint = "run as root or from adb shell"
+		break
+	case errors.Is(err, ts.ErrUinputUnavailable):
+		hint = "kernel needs uinput support (CONFIG_INPUT_UINPUT)"
+		break
+	case errors.Is(err, ts.ErrGrabFailed):
+		hint = "another process holds the touch device, stop it or pick another device"
+		break
+	case errors.Is(err, ts.ErrNoDevice):
+		hint = "connect a touch screen or loosen -device, -name, -vendor and -product"
+		break
+	}
+
+	if hint == "" {
+		return err.Error()
+	}
+	return err.Error() + " (" + hint + ")"
+}
+
+func main() {
+	record := flag.String("record", "", "record real touch input to file instead of running demo swipes")
+	replay := flag.String("replay", "", "replay recorded touch input from file instead of running demo swipes")
+	speed := flag.Float64("speed", 1, "replay speed multiplier")
+	loop := flag.Bool("loop", false, "replay in loop until exit")
+	profile := flag.String("profile", "", "clone touch device described by profile file instead of a real one")
+	saveProfile := flag.String("save-profile", "", "save profile of the touch device to file and exit")
+	devPath := flag.String("device", "", "use touch device at event node path, like /dev/input/event3")
+	devName := flag.String("name", "", "use touch device whose name matches regular expression")
+	vendor := flag.String("vendor", "", "use touch device with vendor id, like 0x1234")
+	product := flag.String("product", "", "use touch device with product id, like 0x5678")
+	choose := flag.Bool("choose", false, "list matching touch devices and ask which one to use")
+	gestureName := flag.String("gesture", "", "run gesture instead of demo swipes: tap, doubletap, multitap, longpress, swipe, humanswipe, drag, fling, pinch or rotate")
+	fromPoint := flag.String("from", "746,1064", "gesture start point as x,y, center of pinch and rotate")
+	toPoint := flag.String("to", "746,1408", "gesture end point as x,y")
+	duration := flag.Duration("duration", 0, "gesture duration, hold time of taps and long presses, 0 picks gesture's default")
+	fromSpan := flag.Float64("span-from", 600, "finger distance at start of pinch")
+	toSpan := flag.Float64("span-to", 200, "finger distance at end of pinch")
+	radius := flag.Float64("radius", 200, "finger distance from center of rotate")
+	angle := flag.Float64("angle", 90, "rotate angle in degrees, clockwise when positive")
+	press := flag.Bool("press", false, "ramp pressure and contact size up after touch down and taper them before lift")
+	roll := flag.Int("roll", 0, "orientation change over the gesture in device units, implies -press")
+	seed := flag.Int64("seed", 0, "random seed of humanswipe, 0 picks one and prints it")
+	rate := flag.Int("rate", gesture.DefaultRate, "touch samples per second of moving gestures")
+	count := flag.Int("count", 3, "taps of multitap")
+	interval := flag.Duration("interval", 0, "time between taps of multitap, 0 picks default")
+	hold := flag.Duration("hold", 0, "pickup and drop hold time of drag, 0 picks defaults")
+	velocity := flag.Float64("velocity", 0, "fling release velocity in pixels per second, 0 picks default")
+	rotation := flag.Int("rotation", 0, "display rotation in degrees: 0, 90, 180 or 270")
+	flag.Parse()
+
+	displayRotation, err := ts.RotationFromDegrees(*rotation)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if *gestureName != "" && !gestureNames[*gestureName] {
+		log.Fatalf("unknown gesture %q\n", *gestureName)
+	}
+
+	from, err := parsePoint(*fromPoint)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	to, err := parsePoint(*toPoint)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	filter, err := parseFilter(*devPath, *devName, *vendor, *product)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var factory ts.DeviceFactory = ts.EvdevFactory{}
+
+	if *saveProfile != "" {
+		tDev, err := pickDevice(factory, filter, *choose)
+		if err != nil {
+			log.Fatalln(diagnose(err))
+		}
+
+		profFile, err := os.Create(*saveProfile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer profFile.Close()
+
+		err = ts.SaveProfile(profFile, tDev)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf("Saved profile of %s to %s\n", tDev.Name, *saveProfile)
+		return
+	}
+
+	if *profile != "" {
+		profFile, err := os.Open(*profile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		prof, err := ts.LoadProfile(profFile)
+		_ = profFile.Close()
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		factory = ts.NewProfileFactory(prof)
+	}
+
+	tDev, err := pickDevice(factory, filter, *choose)
+	if err != nil {
+		log.Fatalln(diagnose(err))
+	}
+
+	//Using Common Display Resolution, 2340x1080
+	sim := ts.NewSimulatorWithFactory(factory)
+	sim.SetStatusHandler(func(message string) {
+		fmt.Println(message)
+	})
+
+	sim.SetRotation(displayRotation)
+
+	err = sim.Start(ts.TYPEB, 1440, 3216, tDev)
+	if err != nil {
+		log.Fatalln(diagnose(err))
+		return
+	}
+
+	if vDev := sim.VirtualDevice(); vDev != nil && vDev.Path != "" {
+		fmt.Printf("Virtual device %s at %s\n", vDev.Name, vDev.Path)
+	}
+
+	if *record != "" {
+		recFile, err := os.Create(*record)
+		if err != nil {
+			sim.Stop()
+			log.Fatalln(err)
+		}
+		defer recFile.Close()
+
+		err = sim.StartRecording(recFile)
+		if err != nil {
+			sim.Stop()
+			log.Fatalln(err)
+		}
+		fmt.Printf("Recording to %s, type exit to stop\n", *record)
+	} else if *replay != "" {
+		recFile, err := os.Open(*replay)
+		if err != nil {
+			sim.Stop()
+			log.Fatalln(err)
+		}
+
+		rec, err := ts.LoadRecording(recFile)
+		_ = recFile.Close()
+		if err != nil {
+			sim.Stop()
+			log.Fatalln(err)
+		}
+
+		go func() {
+			err := sim.Replay(rec, ts.ReplayOptions{Speed: *speed, Loop: *loop})
+			if err == nil {
+				fmt.Printf("Replay finished, type exit to stop\n")
+			}
+		}()
+	} else if *gestureName != "" {
+		time.Sleep(time.Second * 3)
+
+		if *seed == 0 {
+			*seed = time.Now().UnixNano()
+		}
+		human := gesture.Humanize{
+			Rand:      rand.New(rand.NewSource(*seed)),
+			Curve:     0.1,
+			Jitter:    2,
+			Overshoot: 0.03,
+		}
+		if *gestureName == "humanswipe" {
+			fmt.Printf("Humanized with seed %d\n", *seed)
+		}
+
+		opts := gesture.Options{Duration: *duration, Rate: *rate}
+		if *press || *roll != 0 {
+			pressing := gesture.NewPressing(sim.DefaultShape())
+			pressing.Roll = int32(*roll)
+			opts.Shape = pressing
+		}
+
+		err = runGesture(sim, *gestureName, from, to, *count, *interval, *hold, *velocity, human, twoFingerArgs{*fromSpan, *toSpan, *radius, *angle}, opts)
+		if err != nil {
+			log.Println(err)
+		} else {
+			fmt.Printf("Gesture %s done, type exit to stop\n", *gestureName)
+		}
+	} else {
+		time.Sleep(time.Second * 3)
+
+		Swipe(sim, x, y, x, ny, *duration, *rate)
+
+		time.Sleep(time.Second * 3)
+
+		Swipe(sim, nx, y, x, ny, *duration, *rate)
+
+		time.Sleep(time.Second * 3)
+
+		Swipe(sim, x, ny, x, y, *duration, *rate)
+
+		time.Sleep(time.Second * 3)
+
+		Swipe(sim, x, ny, nx, y, *duration, *rate)
+	}
+
+	for {
+		reader := bufio.NewReader(os.Stdin)
+		exit_code, _ := reader.ReadString('\n')
+		fmt.Print(exit_code)
+		if strings.Compare(strings.ToLower(exit_code[:len(exit_code)-1]), "exit") == 0 {
+			sim.Stop()
+			break
+		}
+	}
+
+	if err := sim.Err(); err != nil {
+		log.Println(err)
+	}
+}
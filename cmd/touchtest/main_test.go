@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	ts "kuldippatel.dev/touchsimulation"
+)
+
+func TestPickDeviceClosesScannedDevices(t *testing.T) {
+	scan := func() []*ts.InputDevice {
+		var devs []*ts.InputDevice
+		for _, name := range []string{"touch_a", "touch_b"} {
+			file, err := os.Create(filepath.Join(t.TempDir(), name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			devs = append(devs, &ts.InputDevice{Name: name, File: file})
+		}
+		return devs
+	}
+	isOpen := func(dev *ts.InputDevice) bool {
+		_, err := dev.File.Stat()
+		return err == nil
+	}
+
+	devs := scan()
+	_, err := pickDevice(ts.NewFakeFactory(devs...), ts.DeviceFilter{Name: regexp.MustCompile("^other$")}, false)
+	if !errors.Is(err, ts.ErrNoDevice) {
+		t.Fatalf("pickDevice = %v, want ErrNoDevice", err)
+	}
+	if isOpen(devs[0]) || isOpen(devs[1]) {
+		t.Error("devices left open after no device matched")
+	}
+
+	devs = scan()
+	tDev, err := pickDevice(ts.NewFakeFactory(devs...), ts.DeviceFilter{Name: regexp.MustCompile("_b$")}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tDev != devs[1] || isOpen(devs[0]) || !isOpen(devs[1]) {
+		t.Error("only the picked device should stay open")
+	}
+	ts.CloseDevices(devs, nil)
+}
+
+func TestDiagnoseHints(t *testing.T) {
+	for _, err := range []error{ts.ErrNoDevice, ts.ErrGrabFailed, ts.ErrUinputUnavailable, os.ErrPermission} {
+		if diagnose(err) == err.Error() {
+			t.Errorf("diagnose(%v) gives no hint", err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package gesture
+
+///----------Easing Curves-----------///
+
+// Easing Maps elapsed fraction of a gesture to progress along its path, both from 0 to 1
+type Easing func(t float64) float64
+
+// Linear Constant speed
+func Linear(t float64) float64 {
+	return t
+}
+
+// EaseIn Start slow, then accelerate
+func EaseIn(t float64) float64 {
+	return t * t * t
+}
+
+// EaseOut Start fast, then decelerate
+func EaseOut(t float64) float64 {
+	t = 1 - t
+	return 1 - t*t*t
+}
+
+// EaseInOut Accelerate through the first half, decelerate through the second
+func EaseInOut(t float64) float64 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	t = 2 - 2*t
+	return 1 - t*t*t/2
+}
+
+// MinimumJerk Velocity profile of human reaching movements, smooth start and stop
+// with peak speed in the middle, Flash & Hogan 1985
+func MinimumJerk(t float64) float64 {
+	return t * t * t * (10 + t*(6*t-15))
+}
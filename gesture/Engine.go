@@ -0,0 +1,169 @@
+// Package gesture drives fake touches of a touchsimulation.Simulator along
+// timed paths, scheduled against a monotonic clock.
+package gesture
+
+import (
+	"time"
+)
+
+///----------Gesture Engine-----------///
+
+// DefaultRate Samples per second used when Options.Rate is zero, matches common 120Hz touch panels
+const DefaultRate = 120
+
+// DefaultDuration Gesture duration used when Options.Duration is zero
+const DefaultDuration = 300 * time.Millisecond
+
+// Point Display coordinates
+type Point struct {
+	X int32
+	Y int32
+}
+
+// Injector Fake touch API gestures are built on, satisfied by *touchsimulation.Simulator
+type Injector interface {
+	Down(id int, x, y int32) error
+	Move(id int, x, y int32) error
+	Up(id int) error
+}
+
+// Clock Time source gestures get scheduled against
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// SystemClock Clock of the running system, its times carry monotonic readings
+// so wall clock adjustments don't stretch gestures
+var SystemClock Clock = systemClock{}
+
+// Options Timing of one gesture, zero fields take defaults
+type Options struct {
+	// Duration Time from first to last sample
+	Duration time.Duration
+	// Easing Curve mapping elapsed time to progress along the path, Linear if nil
+	Easing Easing
+	// Rate Samples per second, like the report rate of the touch panel
+	Rate int
+	// Clock Time source, SystemClock if nil
+	Clock Clock
+	// Shape Contact shape over the gesture, the device's default shape if nil.
+	// Needs a FrameInjector, plain Injectors can't change shapes.
+	Shape Shaper
+}
+
+// Fill zero fields with defaults
+func (opts Options) withDefaults() Options {
+	if opts.Duration < 0 {
+		opts.Duration = 0
+	} else if opts.Duration == 0 {
+		opts.Duration = DefaultDuration
+	}
+	if opts.Easing == nil {
+		opts.Easing = Linear
+	}
+	if opts.Rate <= 0 {
+		opts.Rate = DefaultRate
+	}
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+	return opts
+}
+
+// Animate Call step with progress 0, eased progress every 1/Rate seconds, then progress 1
+// once Duration passed. Progress follows the clock, samples due while a step ran late
+// get skipped, so the gesture takes Duration however long its path is.
+func Animate(opts Options, step func(progress float64) error) error {
+	return animate(opts, func(elapsed time.Duration, progress float64) error {
+		return step(progress)
+	})
+}
+
+// Animate, also passing time elapsed since first sample
+func animate(opts Options, step func(elapsed time.Duration, progress float64) error) error {
+	opts = opts.withDefaults()
+
+	clock := opts.Clock
+	interval := time.Second / time.Duration(opts.Rate)
+	start := clock.Now()
+
+	if err := step(0, 0); err != nil {
+		return err
+	}
+
+	for {
+		elapsed := clock.Now().Sub(start)
+		if elapsed >= opts.Duration {
+			break
+		}
+
+		//Wait for next sample on the rate grid
+		next := (elapsed/interval + 1) * interval
+		if next > opts.Duration {
+			next = opts.Duration
+		}
+		clock.Sleep(next - elapsed)
+
+		elapsed = clock.Now().Sub(start)
+		if elapsed >= opts.Duration {
+			break
+		}
+
+		if err := step(elapsed, opts.Easing(float64(elapsed)/float64(opts.Duration))); err != nil {
+			return err
+		}
+	}
+
+	return step(opts.Duration, 1)
+}
+
+// Path Position along a gesture for progress from 0 to 1
+type Path func(progress float64) Point
+
+// Line Straight path from one point to another
+func Line(from, to Point) Path {
+	return func(progress float64) Point {
+		return Point{
+			X: lerp(from.X, to.X, progress),
+			Y: lerp(from.Y, to.Y, progress),
+		}
+	}
+}
+
+// Interpolate between two coordinates, rounding to nearest
+func lerp(from, to int32, progress float64) int32 {
+	value := float64(from) + (float64(to)-float64(from))*progress
+	if value < 0 {
+		return int32(value - 0.5)
+	}
+	return int32(value + 0.5)
+}
+
+// Stroke Touch down fake pointer id at start of path, follow it for opts.Duration and lift it at its end.
+// The pointer gets lifted when injecting fails halfway.
+func Stroke(inj Injector, id int, path Path, opts Options) error {
+	opts = opts.withDefaults()
+	ptr := &pointer{id: id}
+
+	err := animate(opts, func(elapsed time.Duration, progress float64) error {
+		return ptr.update(inj, path(progress), shapeAt(opts, elapsed))
+	})
+
+	return ptr.lift(inj, err)
+}
+
+// Swipe Move fake pointer id in a straight line from one point to another within opts.Duration
+func Swipe(inj Injector, id int, from, to Point, opts Options) error {
+	return Stroke(inj, id, Line(from, to), opts)
+}
@@ -0,0 +1,169 @@
+package gesture
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// Clock advancing only when slept on or by injector lag
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// Injected touch along with clock time it happened at
+type injected struct {
+	Op string
+	Id int
+	At time.Duration
+	Pt Point
+}
+
+// Injector recording calls, optionally failing or lagging
+type recordInjector struct {
+	clock  *fakeClock
+	start  time.Time
+	events []injected
+	lag    time.Duration
+	failAt int
+}
+
+func newRecordInjector(clock *fakeClock) *recordInjector {
+	return &recordInjector{clock: clock, start: clock.now, failAt: -1}
+}
+
+func (r *recordInjector) add(op string, id int, x, y int32) error {
+	if r.failAt == len(r.events) {
+		r.failAt = -1
+		return errors.New("injection failed")
+	}
+	r.events = append(r.events, injected{op, id, r.clock.now.Sub(r.start), Point{x, y}})
+	r.clock.now = r.clock.now.Add(r.lag)
+	return nil
+}
+
+func (r *recordInjector) Down(id int, x, y int32) error {
+	return r.add("down", id, x, y)
+}
+
+func (r *recordInjector) Move(id int, x, y int32) error {
+	return r.add("move", id, x, y)
+}
+
+func (r *recordInjector) Up(id int) error {
+	return r.add("up", id, 0, 0)
+}
+
+func TestSwipeDurationIndependentOfLength(t *testing.T) {
+	for _, to := range []Point{{100, 120}, {100, 2000}} {
+		clock := newFakeClock()
+		inj := newRecordInjector(clock)
+
+		err := Swipe(inj, 0, Point{100, 100}, to, Options{Duration: 300 * time.Millisecond, Rate: 100, Clock: clock})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		first, last := inj.events[0], inj.events[len(inj.events)-1]
+		if first.Op != "down" || first.Pt != (Point{100, 100}) || first.At != 0 {
+			t.Errorf("first event %+v, want down at start point", first)
+		}
+		if last.Op != "up" || last.At != 300*time.Millisecond {
+			t.Errorf("last event %+v, want up after 300ms", last)
+		}
+
+		end := inj.events[len(inj.events)-2]
+		if end.Op != "move" || end.Pt != to {
+			t.Errorf("final move %+v, want %v", end, to)
+		}
+
+		for i := 1; i < len(inj.events)-1; i++ {
+			if gap := inj.events[i].At - inj.events[i-1].At; gap < 10*time.Millisecond && inj.events[i].Pt != to {
+				t.Errorf("event %d only %v after previous one at rate 100", i, gap)
+			}
+		}
+	}
+}
+
+func TestSwipeSampleRate(t *testing.T) {
+	clock := newFakeClock()
+	inj := newRecordInjector(clock)
+
+	err := Swipe(inj, 3, Point{0, 0}, Point{0, 1000}, Options{Duration: 100 * time.Millisecond, Rate: 50, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// down, moves at 20ms steps up to 80ms, final move at 100ms, up
+	if len(inj.events) != 7 {
+		t.Fatalf("got %d events, want 7: %+v", len(inj.events), inj.events)
+	}
+	for i, event := range inj.events[1:5] {
+		want := Point{0, int32(200 * (i + 1))}
+		if event.Pt != want || event.At != time.Duration(i+1)*20*time.Millisecond {
+			t.Errorf("move %d = %+v, want %v at %v", i, event, want, time.Duration(i+1)*20*time.Millisecond)
+		}
+		if event.Id != 3 {
+			t.Errorf("move %d for pointer %d, want 3", i, event.Id)
+		}
+	}
+}
+
+func TestAnimateSkipsLateSamples(t *testing.T) {
+	clock := newFakeClock()
+	inj := newRecordInjector(clock)
+	inj.lag = 25 * time.Millisecond
+
+	err := Swipe(inj, 0, Point{0, 0}, Point{1000, 0}, Options{Duration: 100 * time.Millisecond, Rate: 100, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(inj.events) > 7 {
+		t.Errorf("got %d events, lagging injector should have caused skipped samples", len(inj.events))
+	}
+	for _, event := range inj.events[1 : len(inj.events)-2] {
+		// Progress follows clock, not sample count
+		if want := int32(event.At / time.Millisecond * 10); event.Pt.X != want {
+			t.Errorf("move at %v to %d, want %d", event.At, event.Pt.X, want)
+		}
+	}
+}
+
+func TestStrokeLiftsOnFailure(t *testing.T) {
+	clock := newFakeClock()
+	inj := newRecordInjector(clock)
+	inj.failAt = 3
+
+	err := Swipe(inj, 0, Point{0, 0}, Point{0, 1000}, Options{Clock: clock})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if last := inj.events[len(inj.events)-1]; last.Op != "up" {
+		t.Errorf("last event %+v, want pointer lifted", last)
+	}
+}
+
+func TestEasingEndpoints(t *testing.T) {
+	for name, easing := range map[string]Easing{"Linear": Linear, "EaseIn": EaseIn, "EaseOut": EaseOut, "EaseInOut": EaseInOut, "MinimumJerk": MinimumJerk} {
+		if easing(0) != 0 || easing(1) != 1 {
+			t.Errorf("%s(0) = %v, %s(1) = %v", name, easing(0), name, easing(1))
+		}
+		if v := easing(0.5); math.Abs(v-0.5) > 0.4 {
+			t.Errorf("%s(0.5) = %v", name, v)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package gesture
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	ts "kuldippatel.dev/touchsimulation"
+)
+
+///----------Standard Gestures-----------///
+
+const (
+	// DefaultTapHold Time a tap stays down, well below long press timeouts
+	DefaultTapHold = 50 * time.Millisecond
+	// DefaultTapInterval Time between lift and next touch down of a multi-tap, within double tap timeouts
+	DefaultTapInterval = 100 * time.Millisecond
+	// DefaultLongPress Hold time of long presses, above Android's 500ms long press timeout
+	DefaultLongPress = 800 * time.Millisecond
+	// DefaultDropHold Time a drag rests on its target before lifting
+	DefaultDropHold = 200 * time.Millisecond
+	// DefaultFlingVelocity Release velocity of flings in pixels per second
+	DefaultFlingVelocity = 3000.0
+)
+
+var ErrBadCount = errors.New("tap count must be positive")
+
+// Take given duration, or fallback when it's zero
+func durationOr(d, fallback time.Duration) time.Duration {
+	if d == 0 {
+		return fallback
+	}
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Hold Touch down fake pointer id at point and lift it after hold.
+// With opts.Shape the contact keeps getting reshaped at opts.Rate meanwhile.
+func Hold(inj Injector, id int, at Point, hold time.Duration, opts Options) error {
+	opts = opts.withDefaults()
+
+	if opts.Shape == nil || hold <= 0 {
+		if err := inj.Down(id, at.X, at.Y); err != nil {
+			return err
+		}
+		opts.Clock.Sleep(hold)
+		return inj.Up(id)
+	}
+
+	opts.Duration = hold
+	ptr := &pointer{id: id}
+
+	err := animate(opts, func(elapsed time.Duration, progress float64) error {
+		return ptr.update(inj, at, shapeAt(opts, elapsed))
+	})
+
+	return ptr.lift(inj, err)
+}
+
+// Tap Touch point briefly, opts.Duration sets hold time, DefaultTapHold if zero
+func Tap(inj Injector, id int, at Point, opts Options) error {
+	return Hold(inj, id, at, durationOr(opts.Duration, DefaultTapHold), opts)
+}
+
+// MultiTap Tap point count times, waiting interval between lift and next touch down, DefaultTapInterval if zero
+func MultiTap(inj Injector, id int, at Point, count int, interval time.Duration, opts Options) error {
+	if count < 1 {
+		return ErrBadCount
+	}
+
+	interval = durationOr(interval, DefaultTapInterval)
+	clock := opts.withDefaults().Clock
+
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			clock.Sleep(interval)
+		}
+		if err := Tap(inj, id, at, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DoubleTap Tap point twice within DefaultTapInterval
+func DoubleTap(inj Injector, id int, at Point, opts Options) error {
+	return MultiTap(inj, id, at, 2, 0, opts)
+}
+
+// LongPress Hold point down, opts.Duration sets hold time, DefaultLongPress if zero
+func LongPress(inj Injector, id int, at Point, opts Options) error {
+	return Hold(inj, id, at, durationOr(opts.Duration, DefaultLongPress), opts)
+}
+
+// DragAndHold Hold at from for pickup, DefaultLongPress if zero, move to to within opts.Duration,
+// then rest there for drop, DefaultDropHold if zero, before lifting.
+// opts.Shape spans the whole gesture, from touch down until lift.
+func DragAndHold(inj Injector, id int, from, to Point, pickup, drop time.Duration, opts Options) error {
+	opts = opts.withDefaults()
+
+	pickup = durationOr(pickup, DefaultLongPress)
+	drop = durationOr(drop, DefaultDropHold)
+	total := pickup + opts.Duration + drop
+
+	phases := []struct {
+		opts   Options
+		offset time.Duration
+		path   Path
+	}{
+		{withDuration(opts, pickup), 0, Line(from, from)},
+		{opts, pickup, Line(from, to)},
+		{withDuration(opts, drop), pickup + opts.Duration, Line(to, to)},
+	}
+
+	ptr := &pointer{id: id}
+
+	var err error
+	for _, phase := range phases {
+		phase := phase
+
+		err = animate(phase.opts, func(elapsed time.Duration, progress float64) error {
+			var shape *ts.ContactShape
+			if opts.Shape != nil {
+				s := opts.Shape.ShapeAt(phase.offset+elapsed, total)
+				shape = &s
+			}
+			return ptr.update(inj, phase.path(progress), shape)
+		})
+		if err != nil {
+			break
+		}
+	}
+
+	return ptr.lift(inj, err)
+}
+
+// Same options lasting d, a zero d stays zero instead of taking the default
+func withDuration(opts Options, d time.Duration) Options {
+	opts.Duration = d
+	if d <= 0 {
+		opts.Duration = -1
+	}
+	return opts
+}
+
+// Fling Swipe from one point to another and lift while moving at velocity pixels per second,
+// DefaultFlingVelocity if zero. Without opts.Duration it's chosen so speed builds up evenly
+// to the release velocity; opts.Easing is replaced by a curve ending at that velocity.
+func Fling(inj Injector, id int, from, to Point, velocity float64, opts Options) error {
+	if velocity <= 0 {
+		velocity = DefaultFlingVelocity
+	}
+
+	distance := math.Hypot(float64(to.X-from.X), float64(to.Y-from.Y))
+	if distance == 0 {
+		return Tap(inj, id, from, opts)
+	}
+
+	if opts.Duration == 0 {
+		// Constant acceleration ends at twice the average speed
+		opts.Duration = time.Duration(2 * distance / velocity * float64(time.Second))
+	}
+	if opts.Duration <= 0 {
+		opts.Duration = time.Millisecond
+	}
+
+	// Slope of progress at release, for progress over fraction of duration
+	slope := velocity * opts.Duration.Seconds() / distance
+	opts.Easing = releaseEasing(slope)
+
+	return Stroke(inj, id, Line(from, to), opts)
+}
+
+// Easing from 0 to 1 whose slope at the end is given one
+func releaseEasing(slope float64) Easing {
+	if slope > 2 {
+		return func(t float64) float64 {
+			return math.Pow(t, slope)
+		}
+	}
+
+	// a*t^2 + b*t with a + b = 1 and 2a + b = slope, monotonic for slope in [0, 2]
+	a := slope - 1
+	b := 2 - slope
+	return func(t float64) float64 {
+		return a*t*t + b*t
+	}
+}
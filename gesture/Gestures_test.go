@@ -0,0 +1,139 @@
+package gesture
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// Operations and times of recorded events
+func opsOf(events []injected) ([]string, []time.Duration) {
+	var ops []string
+	var times []time.Duration
+	for _, event := range events {
+		ops = append(ops, event.Op)
+		times = append(times, event.At)
+	}
+	return ops, times
+}
+
+func equalOps(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTapAndLongPress(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(Injector, Options) error
+		hold time.Duration
+	}{
+		{"tap", func(inj Injector, opts Options) error { return Tap(inj, 0, Point{10, 20}, opts) }, DefaultTapHold},
+		{"long press", func(inj Injector, opts Options) error { return LongPress(inj, 0, Point{10, 20}, opts) }, DefaultLongPress},
+	}
+
+	for _, test := range tests {
+		clock := newFakeClock()
+		inj := newRecordInjector(clock)
+
+		if err := test.run(inj, Options{Clock: clock}); err != nil {
+			t.Fatal(err)
+		}
+
+		ops, times := opsOf(inj.events)
+		if !equalOps(ops, []string{"down", "up"}) || times[1] != test.hold {
+			t.Errorf("%s: got %v at %v, want down and up after %v", test.name, ops, times, test.hold)
+		}
+		if inj.events[0].Pt != (Point{10, 20}) {
+			t.Errorf("%s: touched %v", test.name, inj.events[0].Pt)
+		}
+	}
+}
+
+func TestMultiTap(t *testing.T) {
+	clock := newFakeClock()
+	inj := newRecordInjector(clock)
+
+	err := MultiTap(inj, 1, Point{5, 5}, 3, 120*time.Millisecond, Options{Duration: 40 * time.Millisecond, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ops, times := opsOf(inj.events)
+	if !equalOps(ops, []string{"down", "up", "down", "up", "down", "up"}) {
+		t.Fatalf("got %v", ops)
+	}
+
+	want := []time.Duration{0, 40, 160, 200, 320, 360}
+	for i := range want {
+		if times[i] != want[i]*time.Millisecond {
+			t.Errorf("event %d at %v, want %v", i, times[i], want[i]*time.Millisecond)
+		}
+	}
+
+	if MultiTap(inj, 1, Point{5, 5}, 0, 0, Options{Clock: clock}) != ErrBadCount {
+		t.Error("expected ErrBadCount for zero taps")
+	}
+}
+
+func TestDragAndHold(t *testing.T) {
+	clock := newFakeClock()
+	inj := newRecordInjector(clock)
+
+	err := DragAndHold(inj, 0, Point{100, 100}, Point{100, 500}, 0, 0, Options{Duration: 200 * time.Millisecond, Rate: 100, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := inj.events[0]
+	moveStart := inj.events[1]
+	end := inj.events[len(inj.events)-2]
+	last := inj.events[len(inj.events)-1]
+
+	if first.Op != "down" || moveStart.At <= DefaultLongPress {
+		t.Errorf("first move at %v, want after pickup hold of %v", moveStart.At, DefaultLongPress)
+	}
+	if end.Pt != (Point{100, 500}) || end.At != DefaultLongPress+200*time.Millisecond {
+		t.Errorf("last move %+v, want target reached after drag", end)
+	}
+	if last.Op != "up" || last.At-end.At != DefaultDropHold {
+		t.Errorf("lifted %v after reaching target, want %v", last.At-end.At, DefaultDropHold)
+	}
+}
+
+func TestFlingReleaseVelocity(t *testing.T) {
+	for _, test := range []struct {
+		velocity float64
+		duration time.Duration
+	}{
+		{3000, 0},
+		{5000, 100 * time.Millisecond},
+		{1000, 300 * time.Millisecond},
+	} {
+		clock := newFakeClock()
+		inj := newRecordInjector(clock)
+
+		err := Fling(inj, 0, Point{500, 1500}, Point{500, 900}, test.velocity, Options{Duration: test.duration, Rate: 1000, Clock: clock})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		n := len(inj.events)
+		a, b := inj.events[n-4], inj.events[n-2]
+		speed := math.Abs(float64(b.Pt.Y-a.Pt.Y)) / (b.At - a.At).Seconds()
+
+		if math.Abs(speed-test.velocity)/test.velocity > 0.05 {
+			t.Errorf("released at %.0f px/s, want %.0f", speed, test.velocity)
+		}
+		if b.Pt != (Point{500, 900}) {
+			t.Errorf("released at %v, want target", b.Pt)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package gesture
+
+import (
+	"math"
+	"time"
+
+	ts "kuldippatel.dev/touchsimulation"
+)
+
+///----------Multi-Touch Gestures-----------///
+
+// FrameInjector Injector able to change several fake pointers in one frame,
+// satisfied by *touchsimulation.Simulator
+type FrameInjector interface {
+	Injector
+	Frame(ops ...ts.PointerOp) error
+}
+
+// Positions of two fingers opposite each other around center, span apart,
+// angle in degrees turning clockwise on screen from first finger left of second
+func twoFingerAt(center Point, span, angle float64) (Point, Point) {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	offset := vec{cos * span / 2, sin * span / 2}
+
+	c := toVec(center)
+	return c.sub(offset).point(), c.add(offset).point()
+}
+
+// TwoFinger Put two fake pointers down around center, then change their span and angle
+// together within opts.Duration, every step of both fingers in the same frame, and lift both
+func TwoFinger(inj FrameInjector, first, second int, center Point, fromSpan, toSpan, fromAngle, toAngle float64, opts Options) error {
+	opts = opts.withDefaults()
+	fingers := []*pointer{{id: first}, {id: second}}
+
+	err := animate(opts, func(elapsed time.Duration, progress float64) error {
+		a, b := twoFingerAt(center,
+			fromSpan+(toSpan-fromSpan)*progress,
+			fromAngle+(toAngle-fromAngle)*progress)
+		shape := shapeAt(opts, elapsed)
+
+		var ops []ts.PointerOp
+		var changed []*pointer
+		for i, pos := range []Point{a, b} {
+			if op, ok := fingers[i].next(pos, shape); ok {
+				ops = append(ops, op)
+				changed = append(changed, fingers[i])
+			}
+		}
+		if len(ops) == 0 {
+			return nil
+		}
+
+		if err := inj.Frame(ops...); err != nil {
+			return err
+		}
+		for i, finger := range changed {
+			finger.applied(ops[i])
+		}
+		return nil
+	})
+
+	if !fingers[0].down && !fingers[1].down {
+		return err
+	}
+
+	upErr := inj.Frame(ts.UpOp(first), ts.UpOp(second))
+	if err != nil {
+		return err
+	}
+	return upErr
+}
+
+// Pinch Move two fingers lined up horizontally around center from fromSpan to toSpan pixels apart,
+// pinching in when the span shrinks and zooming out when it grows
+func Pinch(inj FrameInjector, first, second int, center Point, fromSpan, toSpan float64, opts Options) error {
+	return TwoFinger(inj, first, second, center, fromSpan, toSpan, 0, 0, opts)
+}
+
+// Rotate Turn two fingers radius pixels away from center by degrees, clockwise on screen when positive
+func Rotate(inj FrameInjector, first, second int, center Point, radius, degrees float64, opts Options) error {
+	return TwoFinger(inj, first, second, center, 2*radius, 2*radius, 0, degrees, opts)
+}
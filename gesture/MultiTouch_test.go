@@ -0,0 +1,80 @@
+package gesture
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	ts "kuldippatel.dev/touchsimulation"
+)
+
+// Injector recording frames of pointer ops
+type frameRecorder struct {
+	*recordInjector
+	frames [][]ts.PointerOp
+}
+
+func (r *frameRecorder) Frame(ops ...ts.PointerOp) error {
+	r.frames = append(r.frames, ops)
+	return nil
+}
+
+func TestPinchFrames(t *testing.T) {
+	clock := newFakeClock()
+	inj := &frameRecorder{recordInjector: newRecordInjector(clock)}
+
+	err := Pinch(inj, 0, 1, Point{540, 1170}, 600, 200, Options{Duration: 100 * time.Millisecond, Rate: 100, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, last := inj.frames[0], inj.frames[len(inj.frames)-1]
+	if len(first) != 2 || first[0] != ts.DownOp(0, 240, 1170) || first[1] != ts.DownOp(1, 840, 1170) {
+		t.Errorf("first frame %+v, want both fingers down 600 apart", first)
+	}
+	if len(last) != 2 || last[0] != ts.UpOp(0) || last[1] != ts.UpOp(1) {
+		t.Errorf("last frame %+v, want both fingers lifted", last)
+	}
+
+	end := inj.frames[len(inj.frames)-2]
+	if len(end) != 2 || end[0] != ts.MoveOp(0, 440, 1170) || end[1] != ts.MoveOp(1, 640, 1170) {
+		t.Errorf("final move frame %+v, want fingers 200 apart", end)
+	}
+
+	for i, frame := range inj.frames[1 : len(inj.frames)-1] {
+		if len(frame) != 2 {
+			t.Errorf("move frame %d has %d ops, want both fingers", i, len(frame))
+		}
+	}
+	if len(inj.events) != 0 {
+		t.Errorf("pinch injected %d single pointer events, want frames only", len(inj.events))
+	}
+}
+
+func TestRotateKeepsRadius(t *testing.T) {
+	clock := newFakeClock()
+	inj := &frameRecorder{recordInjector: newRecordInjector(clock)}
+
+	center := Point{540, 1170}
+	err := Rotate(inj, 2, 3, center, 200, 90, Options{Duration: 100 * time.Millisecond, Rate: 100, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, frame := range inj.frames {
+		for _, op := range frame {
+			if op.Action == ts.POINTERUP {
+				continue
+			}
+			if d := distance(Point{op.X, op.Y}, center); math.Abs(d-200) > 1 {
+				t.Errorf("finger %d at %v is %.1f px from center, want 200", op.Id, Point{op.X, op.Y}, d)
+			}
+		}
+	}
+
+	// Quarter turn clockwise on screen moves the left finger above the center
+	end := inj.frames[len(inj.frames)-2]
+	if end[0] != ts.MoveOp(2, 540, 970) || end[1] != ts.MoveOp(3, 540, 1370) {
+		t.Errorf("final frame %+v, want fingers above and below center", end)
+	}
+}
@@ -0,0 +1,187 @@
+package gesture
+
+import (
+	"math"
+	"time"
+
+	ts "kuldippatel.dev/touchsimulation"
+)
+
+///----------Contact Shape-----------///
+
+// Shaper Contact shape of a finger over a gesture's life
+type Shaper interface {
+	// ShapeAt Shape once elapsed of a gesture lasting total passed
+	ShapeAt(elapsed, total time.Duration) ts.ContactShape
+}
+
+// Default timing of Pressing
+const (
+	DefaultPressIn = 40 * time.Millisecond
+	DefaultRelease = 30 * time.Millisecond
+)
+
+// Share of full pressure and size a finger has while just touching
+const (
+	lightPressure = 0.2
+	lightSize     = 0.6
+)
+
+// Pressing Finger pressing in after touch down, rolling while it moves and easing off
+// before it lifts. Pressure and contact size ramp between a light touch and Base.
+type Pressing struct {
+	// Base Shape of the fully pressed finger, like Simulator.DefaultShape
+	Base ts.ContactShape
+	// PressIn Time pressure and size take to reach Base after touch down
+	PressIn time.Duration
+	// Release Time before lifting in which pressure and size taper off
+	Release time.Duration
+	// Roll Orientation change over the whole gesture, in device units
+	Roll int32
+}
+
+// NewPressing Pressing of base shape with default ramp and taper, without roll
+func NewPressing(base ts.ContactShape) Pressing {
+	return Pressing{
+		Base:    base,
+		PressIn: DefaultPressIn,
+		Release: DefaultRelease,
+	}
+}
+
+// ShapeAt Shape of the finger once elapsed of total passed
+func (p Pressing) ShapeAt(elapsed, total time.Duration) ts.ContactShape {
+	force := 1.0
+	if p.PressIn > 0 && elapsed < p.PressIn {
+		force = float64(elapsed) / float64(p.PressIn)
+	}
+	if left := total - elapsed; p.Release > 0 && left < p.Release {
+		force = math.Min(force, float64(left)/float64(p.Release))
+	}
+	force = math.Max(force, 0)
+
+	pressure := lightPressure + (1-lightPressure)*force
+	size := lightSize + (1-lightSize)*force
+
+	roll := 0.0
+	if total > 0 {
+		roll = float64(p.Roll) * float64(elapsed) / float64(total)
+	}
+
+	scale := func(value int32, factor float64) int32 {
+		return int32(math.Round(float64(value) * factor))
+	}
+
+	return ts.ContactShape{
+		TouchMajor:  scale(p.Base.TouchMajor, size),
+		TouchMinor:  scale(p.Base.TouchMinor, size),
+		WidthMajor:  scale(p.Base.WidthMajor, size),
+		WidthMinor:  scale(p.Base.WidthMinor, size),
+		Orientation: p.Base.Orientation + int32(math.Round(roll)),
+		Pressure:    scale(p.Base.Pressure, pressure),
+	}
+}
+
+// Shape of gesture at elapsed time, nil without opts.Shape
+func shapeAt(opts Options, elapsed time.Duration) *ts.ContactShape {
+	if opts.Shape == nil {
+		return nil
+	}
+
+	shape := opts.Shape.ShapeAt(elapsed, opts.Duration)
+	return &shape
+}
+
+///----------Pointer Driving-----------///
+
+// Fake pointer of a running gesture, reporting only what changed
+type pointer struct {
+	id    int
+	down  bool
+	pos   Point
+	shape *ts.ContactShape
+}
+
+// Op bringing pointer to position and shape, ok is false when nothing changed
+func (p *pointer) next(pos Point, shape *ts.ContactShape) (op ts.PointerOp, ok bool) {
+	if !p.down {
+		op = ts.DownOp(p.id, pos.X, pos.Y)
+	} else {
+		moved := pos != p.pos
+		reshaped := shape != nil && (p.shape == nil || *shape != *p.shape)
+		if !moved && !reshaped {
+			return op, false
+		}
+		op = ts.MoveOp(p.id, pos.X, pos.Y)
+	}
+
+	if shape != nil {
+		op = op.WithShape(*shape)
+	}
+	return op, true
+}
+
+// Mark op got applied
+func (p *pointer) applied(op ts.PointerOp) {
+	p.down = op.Action != ts.POINTERUP
+	p.pos = Point{op.X, op.Y}
+	p.shape = op.Shape
+}
+
+// Touch down or move pointer
+func (p *pointer) update(inj Injector, pos Point, shape *ts.ContactShape) error {
+	op, ok := p.next(pos, shape)
+	if !ok {
+		return nil
+	}
+
+	if err := send(inj, op); err != nil {
+		return err
+	}
+	p.applied(op)
+	return nil
+}
+
+// Lift pointer if it's down, err is the gesture's own failure which wins over lift failures
+func (p *pointer) lift(inj Injector, err error) error {
+	if !p.down {
+		return err
+	}
+
+	upErr := send(inj, ts.UpOp(p.id))
+	p.down = false
+
+	if err != nil {
+		return err
+	}
+	return upErr
+}
+
+// Apply ops in one frame when the injector supports frames, one by one otherwise,
+// dropping shapes plain injectors can't report
+func send(inj Injector, ops ...ts.PointerOp) error {
+	if frames, ok := inj.(FrameInjector); ok {
+		return frames.Frame(ops...)
+	}
+
+	for _, op := range ops {
+		var err error
+		switch op.Action {
+		case ts.POINTERDOWN:
+			err = inj.Down(op.Id, op.X, op.Y)
+			break
+		case ts.POINTERMOVE:
+			err = inj.Move(op.Id, op.X, op.Y)
+			break
+		case ts.POINTERUP:
+			err = inj.Up(op.Id)
+			break
+		default:
+			err = ts.ErrBadPointerOp
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
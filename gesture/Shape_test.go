@@ -0,0 +1,94 @@
+package gesture
+
+import (
+	"testing"
+	"time"
+
+	ts "kuldippatel.dev/touchsimulation"
+)
+
+var testBase = ts.ContactShape{TouchMajor: 50, WidthMajor: 50, Orientation: 0, Pressure: 100}
+
+func TestPressingShape(t *testing.T) {
+	p := Pressing{Base: testBase, PressIn: 40 * time.Millisecond, Release: 20 * time.Millisecond, Roll: 30}
+	total := 200 * time.Millisecond
+
+	tests := []struct {
+		elapsed  time.Duration
+		pressure int32
+		major    int32
+		roll     int32
+	}{
+		{0, 20, 30, 0},
+		{20 * time.Millisecond, 60, 40, 3},
+		{100 * time.Millisecond, 100, 50, 15},
+		{190 * time.Millisecond, 60, 40, 29},
+		{total, 20, 30, 30},
+	}
+
+	for _, test := range tests {
+		shape := p.ShapeAt(test.elapsed, total)
+		if shape.Pressure != test.pressure || shape.TouchMajor != test.major || shape.Orientation != test.roll {
+			t.Errorf("at %v got %+v, want pressure %d, major %d, orientation %d", test.elapsed, shape, test.pressure, test.major, test.roll)
+		}
+	}
+}
+
+func TestStrokeShape(t *testing.T) {
+	clock := newFakeClock()
+	inj := &frameRecorder{recordInjector: newRecordInjector(clock)}
+
+	opts := Options{Duration: 100 * time.Millisecond, Rate: 100, Clock: clock, Shape: NewPressing(testBase)}
+	if err := Swipe(inj, 0, Point{0, 0}, Point{0, 1000}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	var pressures []int32
+	for _, frame := range inj.frames {
+		for _, op := range frame {
+			if op.Action == ts.POINTERUP {
+				continue
+			}
+			if op.Shape == nil {
+				t.Fatalf("op %+v without shape", op)
+			}
+			pressures = append(pressures, op.Shape.Pressure)
+		}
+	}
+
+	n := len(pressures)
+	if pressures[0] != 20 || pressures[n/2] != 100 || pressures[n-1] != 20 {
+		t.Errorf("pressures %v, want ramp from 20 to 100 and taper back to 20", pressures)
+	}
+}
+
+func TestLongPressReshapes(t *testing.T) {
+	clock := newFakeClock()
+	inj := &frameRecorder{recordInjector: newRecordInjector(clock)}
+
+	opts := Options{Duration: 200 * time.Millisecond, Rate: 100, Clock: clock, Shape: Pressing{Base: testBase, Roll: 20}}
+	if err := LongPress(inj, 0, Point{300, 300}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	first, end := inj.frames[0][0], inj.frames[len(inj.frames)-2][0]
+	if first.Action != ts.POINTERDOWN || end.Action != ts.POINTERMOVE || end.Shape.Orientation != 20 {
+		t.Errorf("long press went from %+v to %+v, want finger rolled to 20 in place", first, end)
+	}
+	if end.X != 300 || end.Y != 300 {
+		t.Errorf("finger moved to %d,%d while pressing", end.X, end.Y)
+	}
+}
+
+func TestShapeNeedsFrames(t *testing.T) {
+	clock := newFakeClock()
+	inj := newRecordInjector(clock)
+
+	opts := Options{Duration: 50 * time.Millisecond, Clock: clock, Shape: NewPressing(testBase)}
+	if err := Swipe(inj, 0, Point{0, 0}, Point{0, 100}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if inj.events[0].Op != "down" || inj.events[len(inj.events)-1].Op != "up" {
+		t.Errorf("plain injector got %+v", inj.events)
+	}
+}
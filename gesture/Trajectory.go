@@ -0,0 +1,207 @@
+package gesture
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+///----------Trajectories-----------///
+
+// Point with fractional coordinates used while building paths
+type vec struct {
+	X float64
+	Y float64
+}
+
+func toVec(p Point) vec {
+	return vec{float64(p.X), float64(p.Y)}
+}
+
+func (v vec) add(o vec) vec {
+	return vec{v.X + o.X, v.Y + o.Y}
+}
+
+func (v vec) sub(o vec) vec {
+	return vec{v.X - o.X, v.Y - o.Y}
+}
+
+func (v vec) scale(f float64) vec {
+	return vec{v.X * f, v.Y * f}
+}
+
+func (v vec) length() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
+// Rotated by 90 degrees clockwise on screen, where y points down
+func (v vec) normal() vec {
+	return vec{-v.Y, v.X}
+}
+
+// Round to nearest pixel
+func (v vec) point() Point {
+	return Point{int32(math.Round(v.X)), int32(math.Round(v.Y))}
+}
+
+// Bezier Bezier curve starting at first control point and ending at last one
+func Bezier(points ...Point) Path {
+	controls := make([]vec, len(points))
+	for i, p := range points {
+		controls[i] = toVec(p)
+	}
+
+	return func(progress float64) Point {
+		return bezierAt(controls, progress).point()
+	}
+}
+
+// Evaluate Bezier curve with de Casteljau's algorithm
+func bezierAt(controls []vec, t float64) vec {
+	if len(controls) == 0 {
+		return vec{}
+	}
+
+	work := make([]vec, len(controls))
+	copy(work, controls)
+
+	for n := len(work) - 1; n > 0; n-- {
+		for i := 0; i < n; i++ {
+			work[i] = work[i].add(work[i+1].sub(work[i]).scale(t))
+		}
+	}
+	return work[0]
+}
+
+// Arc Circular arc from one point to another, bend is distance of the arc's middle from the
+// straight line as fraction of that line's length, positive bends clockwise on screen,
+// to the right of the direction of travel
+func Arc(from, to Point, bend float64) Path {
+	start, end := toVec(from), toVec(to)
+
+	chord := end.sub(start)
+	length := chord.length()
+	if bend == 0 || length == 0 {
+		return Line(from, to)
+	}
+
+	half := length / 2
+	sagitta := bend * length
+	radius := (half*half + sagitta*sagitta) / (2 * math.Abs(sagitta))
+
+	normal := chord.normal().scale(1 / length)
+	mid := start.add(chord.scale(0.5))
+	apex := mid.add(normal.scale(sagitta))
+	center := mid.add(normal.scale(sagitta - math.Copysign(radius, sagitta)))
+
+	// Sweep to the apex is half of the whole arc
+	d0 := start.sub(center)
+	dA := apex.sub(center)
+	sweep := 2 * math.Atan2(d0.X*dA.Y-d0.Y*dA.X, d0.X*dA.X+d0.Y*dA.Y)
+
+	return func(progress float64) Point {
+		if progress >= 1 {
+			return to
+		}
+
+		angle := sweep * progress
+		sin, cos := math.Sincos(angle)
+		return center.add(vec{d0.X*cos - d0.Y*sin, d0.X*sin + d0.Y*cos}).point()
+	}
+}
+
+// Humanize Variations making paths look drawn by a finger, zero fields disable their effect
+type Humanize struct {
+	// Rand Source of every variation, seed it to reproduce paths exactly; time seeded if nil
+	Rand *rand.Rand
+	// Curve Largest sideways bend as fraction of path length, like 0.1
+	Curve float64
+	// Jitter Largest positional noise in pixels, endpoints stay exact
+	Jitter float64
+	// Overshoot Largest distance past the end as fraction of path length, corrected before lifting
+	Overshoot float64
+}
+
+// Noise knots of jitter along a path
+const jitterKnots = 8
+
+// Share of progress spent reaching the overshoot point
+const overshootShare = 0.85
+
+// Path Build path from one point to another with random curve, jitter and overshoot.
+// Every random value is drawn here, so the path only depends on progress and the seed.
+func (h Humanize) Path(from, to Point) Path {
+	rnd := h.Rand
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	// symmetric random value in [-1, 1]
+	signed := func() float64 {
+		return rnd.Float64()*2 - 1
+	}
+
+	start, end := toVec(from), toVec(to)
+	chord := end.sub(start)
+	length := chord.length()
+
+	target := end
+	if h.Overshoot > 0 && length > 0 {
+		over := h.Overshoot * (0.5 + rnd.Float64()/2)
+		target = end.add(chord.scale(over))
+	}
+
+	//Cubic curve, both controls pushed sideways the same way but not as far
+	sideways := chord.normal()
+	bend := h.Curve * signed()
+	controls := []vec{
+		start,
+		start.add(chord.scale(1.0 / 3)).add(sideways.scale(bend * (0.5 + rnd.Float64()/2))),
+		start.add(chord.scale(2.0 / 3)).add(sideways.scale(bend * (0.5 + rnd.Float64()/2))),
+		target,
+	}
+
+	var knots []vec
+	if h.Jitter > 0 {
+		knots = make([]vec, jitterKnots+1)
+		for i := 1; i < jitterKnots; i++ {
+			knots[i] = vec{signed() * h.Jitter, signed() * h.Jitter}
+		}
+	}
+
+	return func(progress float64) Point {
+		var pos vec
+		if target == end {
+			pos = bezierAt(controls, progress)
+		} else if progress < overshootShare {
+			pos = bezierAt(controls, progress/overshootShare)
+		} else {
+			back := (progress - overshootShare) / (1 - overshootShare)
+			pos = target.add(end.sub(target).scale(MinimumJerk(math.Min(back, 1))))
+		}
+
+		return pos.add(jitterAt(knots, progress)).point()
+	}
+}
+
+// Smoothly interpolated noise between knots, zero at both ends
+func jitterAt(knots []vec, progress float64) vec {
+	if len(knots) == 0 || progress <= 0 || progress >= 1 {
+		return vec{}
+	}
+
+	pos := progress * float64(len(knots)-1)
+	idx := int(pos)
+	frac := pos - float64(idx)
+	frac = (1 - math.Cos(frac*math.Pi)) / 2
+
+	return knots[idx].add(knots[idx+1].sub(knots[idx]).scale(frac))
+}
+
+// HumanSwipe Swipe along humanized path, following minimum-jerk velocity unless opts.Easing is set
+func HumanSwipe(inj Injector, id int, from, to Point, h Humanize, opts Options) error {
+	if opts.Easing == nil {
+		opts.Easing = MinimumJerk
+	}
+	return Stroke(inj, id, h.Path(from, to), opts)
+}
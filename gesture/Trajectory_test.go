@@ -0,0 +1,120 @@
+package gesture
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func distance(a, b Point) float64 {
+	return math.Hypot(float64(a.X-b.X), float64(a.Y-b.Y))
+}
+
+func TestBezier(t *testing.T) {
+	path := Bezier(Point{0, 0}, Point{100, 200}, Point{200, 0})
+
+	if p := path(0); p != (Point{0, 0}) {
+		t.Errorf("start %v", p)
+	}
+	if p := path(1); p != (Point{200, 0}) {
+		t.Errorf("end %v", p)
+	}
+	if p := path(0.5); p != (Point{100, 100}) {
+		t.Errorf("middle %v, want (100, 100)", p)
+	}
+}
+
+func TestArc(t *testing.T) {
+	from, to := Point{0, 0}, Point{400, 0}
+
+	for _, bend := range []float64{0.25, -0.25, 0.5, 0.8} {
+		path := Arc(from, to, bend)
+
+		if p := path(0); p != from {
+			t.Errorf("bend %v: start %v", bend, p)
+		}
+		if p := path(1); p != to {
+			t.Errorf("bend %v: end %v", bend, p)
+		}
+
+		// Middle of the arc lies bend * length to the right of travel, below the chord on screen
+		want := Point{200, int32(math.Round(bend * 400))}
+		if p := path(0.5); distance(p, want) > 1 {
+			t.Errorf("bend %v: middle %v, want %v", bend, p, want)
+		}
+	}
+
+	if p := Arc(from, to, 0)(0.5); p != (Point{200, 0}) {
+		t.Errorf("straight arc middle %v", p)
+	}
+}
+
+func TestHumanizeReproducible(t *testing.T) {
+	from, to := Point{100, 1500}, Point{600, 400}
+
+	newHumanize := func(seed int64) Humanize {
+		return Humanize{Rand: rand.New(rand.NewSource(seed)), Curve: 0.15, Jitter: 4, Overshoot: 0.05}
+	}
+
+	a := newHumanize(42).Path(from, to)
+	b := newHumanize(42).Path(from, to)
+	c := newHumanize(7).Path(from, to)
+
+	differs := false
+	for i := 0; i <= 100; i++ {
+		progress := float64(i) / 100
+		if a(progress) != b(progress) {
+			t.Fatalf("same seed differs at %v: %v vs %v", progress, a(progress), b(progress))
+		}
+		if a(progress) != c(progress) {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Error("different seeds gave identical paths")
+	}
+
+	if p := a(0); p != from {
+		t.Errorf("start %v, want %v", p, from)
+	}
+	if p := a(1); p != to {
+		t.Errorf("end %v, want %v", p, to)
+	}
+}
+
+func TestHumanizeOvershootAndJitter(t *testing.T) {
+	from, to := Point{0, 0}, Point{0, 1000}
+
+	path := Humanize{Rand: rand.New(rand.NewSource(1)), Overshoot: 0.1}.Path(from, to)
+	if p := path(overshootShare); p.Y < 1050 || p.Y > 1100 {
+		t.Errorf("overshoot point %v, want 5-10%% past the end", p)
+	}
+
+	path = Humanize{Rand: rand.New(rand.NewSource(1)), Jitter: 3}.Path(from, to)
+	for i := 0; i <= 100; i++ {
+		progress := float64(i) / 100
+		if d := distance(path(progress), Line(from, to)(progress)); d > 3*math.Sqrt2+1 {
+			t.Errorf("jitter of %.1f px at %v exceeds bound", d, progress)
+		}
+	}
+}
+
+func TestHumanSwipeMinimumJerk(t *testing.T) {
+	clock := newFakeClock()
+	inj := newRecordInjector(clock)
+
+	err := HumanSwipe(inj, 0, Point{0, 0}, Point{0, 1000}, Humanize{Rand: rand.New(rand.NewSource(3))}, Options{Duration: 100 * time.Millisecond, Rate: 100, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Slow start and stop, fastest in the middle
+	moves := inj.events[1 : len(inj.events)-1]
+	first := moves[0].Pt.Y
+	middle := moves[5].Pt.Y - moves[4].Pt.Y
+	last := moves[len(moves)-1].Pt.Y - moves[len(moves)-2].Pt.Y
+	if first >= middle || last >= middle {
+		t.Errorf("steps %d, %d, %d don't follow minimum-jerk profile", first, middle, last)
+	}
+}